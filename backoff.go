@@ -0,0 +1,97 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// BackoffOption represents an option of the exponential backoff.
+type BackoffOption func(*backoff)
+
+// backoff represents the configuration and state of an exponential backoff.
+type backoff struct {
+	base, max time.Duration
+	factor    float64
+	jitter    float64         // Ratio of the random jitter applied to each delay
+	rand      *random         // Source of randomness of the jitter
+	onError   func(err error) // Optional handler for the errors
+	delay     time.Duration   // Current delay
+	stop      atomic.Bool     // Whether the backoff was cancelled
+}
+
+// WithJitter applies a random jitter of up to 'ratio' of the delay, in both directions. For
+// example, a ratio of 0.1 means that a delay of 1s would vary between 900ms and 1.1s.
+func WithJitter(ratio float64) BackoffOption {
+	return func(b *backoff) {
+		b.jitter = ratio
+	}
+}
+
+// WithErrorHandler forwards the errors returned by the task to the handler.
+func WithErrorHandler(handler func(err error)) BackoffOption {
+	return func(b *backoff) {
+		b.onError = handler
+	}
+}
+
+// RunBackoff schedules a task to run on the next tick and then every 'base' interval. If
+// the task fails, it is retried with an exponentially increasing delay, multiplied by
+// 'factor' on every consecutive failure and capped at 'max'. On success, the delay is
+// reset to 'base'. It returns a cancel function to stop the task.
+func (s *Scheduler) RunBackoff(task func() error, base, max time.Duration, factor float64, options ...BackoffOption) context.CancelFunc {
+	b := &backoff{
+		base:   base,
+		max:    max,
+		factor: factor,
+		delay:  base,
+		rand:   &s.rand,
+	}
+
+	for _, opt := range options {
+		opt(b)
+	}
+
+	s.RunDynamic(func(now time.Time, elapsed time.Duration) time.Duration {
+		if b.stop.Load() {
+			return 0
+		}
+
+		return b.next(task())
+	})
+
+	return func() {
+		b.stop.Store(true)
+	}
+}
+
+// next computes the next delay, based on the outcome of the task
+func (b *backoff) next(err error) time.Duration {
+	switch {
+	case err == nil:
+		b.delay = b.base
+		return b.withJitter(b.base)
+	case b.onError != nil:
+		b.onError(err)
+	}
+
+	// Use the current delay and then increase it
+	delay := b.delay
+	if b.delay = time.Duration(float64(b.delay) * b.factor); b.delay > b.max {
+		b.delay = b.max
+	}
+
+	return b.withJitter(delay)
+}
+
+// withJitter applies a random jitter to the delay
+func (b *backoff) withJitter(delay time.Duration) time.Duration {
+	if b.jitter <= 0 {
+		return delay
+	}
+
+	return delay + time.Duration(float64(delay)*b.jitter*(2*b.rand.Float64()-1))
+}
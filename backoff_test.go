@@ -0,0 +1,82 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunBackoff(t *testing.T) {
+	now := time.Unix(0, 0)
+	runs := make([]time.Duration, 0, 8)
+	errs := 0
+
+	// Fail 4 times, then succeed
+	s := newScheduler(now)
+	cancel := s.RunBackoff(func() error {
+		runs = append(runs, s.Now().Sub(now))
+		if len(runs) <= 4 {
+			return fmt.Errorf("failed")
+		}
+		return nil
+	}, 100*time.Millisecond, 300*time.Millisecond, 2, WithErrorHandler(func(err error) {
+		errs++
+	}))
+
+	for i := 0; i < 120; i++ {
+		s.Tick()
+	}
+
+	// Tick() already advanced the clock when the task runs, hence +10ms
+	assert.Equal(t, []time.Duration{
+		10 * time.Millisecond,   // first run
+		110 * time.Millisecond,  // +100ms
+		310 * time.Millisecond,  // +200ms
+		610 * time.Millisecond,  // +300ms (capped)
+		910 * time.Millisecond,  // +300ms (capped)
+		1010 * time.Millisecond, // +100ms (reset)
+		1110 * time.Millisecond, // +100ms
+	}, runs)
+	assert.Equal(t, 4, errs)
+
+	// Cancel the backoff
+	cancel()
+	for i := 0; i < 100; i++ {
+		s.Tick()
+	}
+	assert.Len(t, runs, 7)
+}
+
+func TestRunBackoffAtLimit(t *testing.T) {
+	now := time.Unix(0, 0)
+	runs := 0
+
+	// The limit is reached by the first run, the retries are still scheduled
+	s := newScheduler(now, WithMaxJobs(4))
+	limit := fill(s)
+	s.RunBackoff(func() error {
+		if runs++; runs == 1 {
+			limit(now, 0)
+		}
+		return fmt.Errorf("failed")
+	}, 100*time.Millisecond, 100*time.Millisecond, 2)
+
+	s.Advance(time.Second)
+	assert.Equal(t, 10, runs)
+}
+
+func TestBackoffJitter(t *testing.T) {
+	b := &backoff{base: time.Second, max: time.Second, factor: 1, rand: new(random)}
+	WithJitter(0.1)(b)
+
+	for i := 0; i < 100; i++ {
+		delay := b.next(nil)
+		assert.GreaterOrEqual(t, delay, 900*time.Millisecond)
+		assert.LessOrEqual(t, delay, 1100*time.Millisecond)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// RunDaily schedules a task to run every day at the time of day 'at' in the location, for
+// example 9*time.Hour + 30*time.Minute for 09:30. The next occurrence is computed on the wall
+// clock of the location, so the task keeps running at the same local time across daylight
+// saving changes. If the location is nil, the local time zone is used. The task stops when it
+// returns false, or when the returned cancel function is called.
+func (s *Scheduler) RunDaily(task Task, at time.Duration, loc *time.Location) context.CancelFunc {
+	return s.runCalendar(task, func(now time.Time) time.Time {
+		return nextDaily(now, at, loc)
+	})
+}
+
+// RunWeekly schedules a task to run every week on the weekday, at the time of day 'at' in the
+// location. It otherwise behaves exactly like RunDaily.
+func (s *Scheduler) RunWeekly(task Task, weekday time.Weekday, at time.Duration, loc *time.Location) context.CancelFunc {
+	return s.runCalendar(task, func(now time.Time) time.Time {
+		return nextWeekly(now, weekday, at, loc)
+	})
+}
+
+// runCalendar runs the task at the occurrences computed by 'next', re-arming it after every run
+func (s *Scheduler) runCalendar(task Task, next func(now time.Time) time.Time) context.CancelFunc {
+	var stop atomic.Bool
+	s.scheduleDynamic(func(now time.Time, elapsed time.Duration) time.Duration {
+		if stop.Load() || !task(now, elapsed) {
+			return 0
+		}
+
+		return next(now).Sub(now)
+	}, tickOf(next(s.Now())))
+	return func() {
+		stop.Store(true)
+	}
+}
+
+// nextDaily returns the first occurrence of the time of day strictly after 'now'
+func nextDaily(now time.Time, at time.Duration, loc *time.Location) time.Time {
+	local := now.In(locationOf(loc))
+	next := atDay(local, 0, at)
+	if !next.After(now) {
+		next = atDay(local, 1, at)
+	}
+	return next
+}
+
+// nextWeekly returns the first occurrence of the weekday and time of day strictly after 'now'
+func nextWeekly(now time.Time, weekday time.Weekday, at time.Duration, loc *time.Location) time.Time {
+	local := now.In(locationOf(loc))
+	days := (int(weekday) - int(local.Weekday()) + 7) % 7
+	next := atDay(local, days, at)
+	if !next.After(now) {
+		next = atDay(local, days+7, at)
+	}
+	return next
+}
+
+// atDay returns the time of day 'at' on the wall clock, 'days' after the date of 'local'. Using
+// the wall clock rather than adding durations keeps the time of day across daylight saving.
+// If the time of day is skipped by a daylight saving change, the time right after the gap is
+// returned instead.
+func atDay(local time.Time, days int, at time.Duration) time.Time {
+	y, m, d := local.Date()
+	hour, min, sec, nsec := int(at/time.Hour), int(at%time.Hour/time.Minute), int(at%time.Minute/time.Second), int(at%time.Second)
+	next := time.Date(y, m, d+days, hour, min, sec, nsec, local.Location())
+	if next.Hour() == hour%24 && next.Minute() == min {
+		return next
+	}
+
+	// The wall clock does not exist on that day, apply the offset in effect before the gap
+	_, offset := time.Date(y, m, d+days, 0, 0, 0, 0, local.Location()).Zone()
+	return time.Date(y, m, d+days, hour, min, sec, nsec, time.UTC).
+		Add(-time.Duration(offset) * time.Second).
+		In(local.Location())
+}
+
+// locationOf returns the location, or the local time zone if nil
+func locationOf(loc *time.Location) *time.Location {
+	if loc == nil {
+		return time.Local
+	}
+	return loc
+}
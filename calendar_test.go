@@ -0,0 +1,109 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunDaily(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, loc)
+	var runs []time.Time
+
+	s := newScheduler(now)
+	cancel := s.RunDaily(func(now time.Time, _ time.Duration) bool {
+		runs = append(runs, now.In(loc))
+		return true
+	}, 9*time.Hour+30*time.Minute, loc)
+
+	s.Advance(time.Second)
+	assert.Empty(t, runs)
+
+	for day := 2; day <= 3; day++ {
+		s.Seek(time.Date(2024, 1, day, 9, 29, 59, 0, loc))
+		s.Advance(2 * time.Second)
+	}
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 1, 2, 9, 30, 0, 0, loc),
+		time.Date(2024, 1, 3, 9, 30, 0, 0, loc),
+	}, runs)
+
+	cancel()
+	s.Seek(time.Date(2024, 1, 4, 9, 29, 59, 0, loc))
+	s.Advance(2 * time.Second)
+	assert.Len(t, runs, 2)
+}
+
+func TestRunDailyAtLimit(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	var runs int
+
+	// The limit is reached by the first run, the next days are still scheduled
+	s := newScheduler(now, WithMaxJobs(4))
+	limit := fill(s)
+	s.RunDaily(func(now time.Time, elapsed time.Duration) bool {
+		runs++
+		return limit(now, elapsed)
+	}, 9*time.Hour+30*time.Minute, time.UTC)
+
+	for day := 2; day <= 4; day++ {
+		s.Seek(time.Date(2024, 1, day, 9, 29, 59, 0, time.UTC))
+		s.Advance(2 * time.Second)
+	}
+	assert.Equal(t, 3, runs)
+}
+
+func TestRunWeekly(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC) // Monday
+	var runs []time.Time
+
+	s := newScheduler(now)
+	s.RunWeekly(func(now time.Time, _ time.Duration) bool {
+		runs = append(runs, now.UTC())
+		return len(runs) < 2
+	}, time.Monday, 8*time.Hour, time.UTC)
+
+	for day := 8; day <= 22; day += 7 {
+		s.Seek(time.Date(2024, 1, day, 7, 59, 59, 0, time.UTC))
+		s.Advance(2 * time.Second)
+	}
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 1, 8, 8, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 15, 8, 0, 0, 0, time.UTC),
+	}, runs)
+}
+
+func TestNextDaily(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone database is not available")
+	}
+
+	tests := []struct {
+		now, next time.Time
+	}{
+		{now: time.Date(2024, 1, 1, 1, 0, 0, 0, ny), next: time.Date(2024, 1, 1, 2, 30, 0, 0, ny)},
+		{now: time.Date(2024, 1, 31, 3, 0, 0, 0, ny), next: time.Date(2024, 2, 1, 2, 30, 0, 0, ny)},
+		{now: time.Date(2024, 12, 31, 3, 0, 0, 0, ny), next: time.Date(2025, 1, 1, 2, 30, 0, 0, ny)},
+		{now: time.Date(2024, 3, 9, 3, 0, 0, 0, ny), next: time.Date(2024, 3, 10, 3, 30, 0, 0, ny)}, // 2:30 does not exist
+		{now: time.Date(2024, 11, 2, 3, 0, 0, 0, ny), next: time.Date(2024, 11, 3, 2, 30, 0, 0, ny)},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.next, nextDaily(tc.now, 2*time.Hour+30*time.Minute, ny))
+	}
+}
+
+func TestNextWeekly(t *testing.T) {
+	now := time.Date(2024, 1, 3, 12, 0, 0, 0, time.UTC) // Wednesday
+	assert.Equal(t, time.Date(2024, 1, 3, 13, 0, 0, 0, time.UTC), nextWeekly(now, time.Wednesday, 13*time.Hour, time.UTC))
+	assert.Equal(t, nextWeekly(now, time.Wednesday, 13*time.Hour, time.Local), nextWeekly(now, time.Wednesday, 13*time.Hour, nil))
+	assert.Equal(t, time.Date(2024, 1, 10, 11, 0, 0, 0, time.UTC), nextWeekly(now, time.Wednesday, 11*time.Hour, time.UTC))
+	assert.Equal(t, time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC), nextWeekly(now, time.Monday, 0, time.UTC))
+	assert.Equal(t, time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC), nextWeekly(now, time.Saturday, 0, time.UTC))
+}
@@ -0,0 +1,108 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"time"
+)
+
+// Coarse is the resolution of the second-tier wheel of the coarse-grained jobs, and the
+// default tolerance of those jobs, see RunAfterCoarse.
+const Coarse = 100 * time.Millisecond
+
+// coarseTicks is the number of ticks of the wheel per tick of the coarse wheel
+const coarseTicks = tick(Coarse / resolution)
+
+// RunAfterCoarse schedules a task to run once after a 'delay', for a job which tolerates running
+// up to 'tolerance' late. Rather than on its exact tick, the job runs on the next multiple of the
+// tolerance on the scheduler's clock, so that the coarse-grained jobs due around the same time
+// run together on the same tick.
+//
+// The jobs with a tolerance of at least Coarse wait in a second-tier wheel, which is ticked once
+// every Coarse and spans a hundred times longer than the main one. On each of its ticks, the jobs
+// due before the next one cascade into the main wheel, so a job scheduled far ahead is looked at
+// once per rotation of the coarse wheel rather than once per rotation of the main one. A zero
+// tolerance defaults to Coarse. The jobs with a smaller tolerance, or due too soon to wait in the
+// coarse wheel, are kept in the main wheel, with tolerances rounded up to the resolution.
+func (s *Scheduler) RunAfterCoarse(task Task, delay, tolerance time.Duration) {
+	when := s.coarsen(s.after(delay), tolerance)
+	job := job{
+		Task:  task,
+		RunAt: when,
+		Since: span(when - s.now()),
+	}
+
+	// Wait in the coarse wheel only if the job is due after the next coarse tick, so that
+	// it can't miss the tick on which it cascades into the main wheel
+	if tolerance > 0 && tolerance < Coarse || when/coarseTicks < s.now()/coarseTicks+2 {
+		s.enqueueJob(job)
+		return
+	}
+
+	if !s.admit() {
+		return
+	}
+
+	if s.stable {
+		job.Seq = s.seq.Add(1) & sequenceMask
+	}
+
+	bucket := s.coarseOf(when)
+	s.counters.scheduled.Add(1)
+	bucket.mu.Lock()
+	bucket.queue = append(bucket.queue, job)
+	bucket.mu.Unlock()
+}
+
+// coarsen rounds the tick up to the next multiple of the tolerance.
+func (s *Scheduler) coarsen(at tick, tolerance time.Duration) tick {
+	switch {
+	case tolerance == 0:
+		tolerance = Coarse
+	case tolerance < resolution:
+		tolerance = resolution
+	}
+
+	step := tick(durationOf(tolerance))
+	if r := at % step; r != 0 {
+		at += step - r
+	}
+	return at
+}
+
+// cascade moves the jobs of the coarse bucket which are due before the coarse tick following
+// 'now' into the main wheel. The overdue ones are promoted so that they run on the tick 'now'.
+func (s *Scheduler) cascade(bucket *bucket, now tick) {
+	offset := 0
+	bucket.mu.Lock()
+	bucket.peak = len(bucket.queue)
+	due := make([]job, 0, len(bucket.queue))
+	for i, job := range bucket.queue {
+		if job.RunAt >= now+coarseTicks {
+			bucket.queue[offset] = bucket.queue[i]
+			offset++
+			continue
+		}
+
+		if job.RunAt < now {
+			job.Since += span(now - job.RunAt)
+			job.RunAt = now
+		}
+		due = append(due, job)
+	}
+	clear(bucket.queue[offset:]) // release the closures of the removed jobs
+	bucket.queue = bucket.queue[:offset]
+	bucket.mu.Unlock()
+
+	for _, job := range due {
+		s.insert(job)
+	}
+	s.compact(bucket)
+}
+
+// coarseOf returns the bucket of the coarse wheel for a given tick.
+func (s *Scheduler) coarseOf(when tick) *bucket {
+	idx := int(when/coarseTicks) % numBuckets
+	return s.coarse[idx]
+}
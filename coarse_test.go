@@ -0,0 +1,90 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunAfterCoarse(t *testing.T) {
+	s := newScheduler(time.Unix(0, 0))
+	s.Advance(30 * time.Millisecond)
+
+	var fired []time.Duration
+	for _, delay := range []time.Duration{0, 20 * time.Millisecond, 70 * time.Millisecond, 150 * time.Millisecond} {
+		s.RunAfterCoarse(func(now time.Time, _ time.Duration) bool {
+			fired = append(fired, now.Sub(time.Unix(0, 0)))
+			return false
+		}, delay, 0)
+	}
+
+	// The jobs are batched on the multiples of the default tolerance
+	s.Advance(300 * time.Millisecond)
+	assert.Equal(t, []time.Duration{
+		100 * time.Millisecond,
+		100 * time.Millisecond,
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+	}, fired)
+}
+
+func TestRunAfterCoarseWheel(t *testing.T) {
+	s := newScheduler(time.Unix(0, 0))
+
+	var fired []time.Duration
+	for _, delay := range []time.Duration{time.Minute, 5 * time.Second, 250 * time.Millisecond} {
+		s.RunAfterCoarse(func(now time.Time, _ time.Duration) bool {
+			fired = append(fired, now.Sub(time.Unix(0, 0)))
+			return false
+		}, delay, time.Second)
+	}
+
+	// The jobs wait in the coarse wheel, outside of the main one
+	assert.Len(t, s.Jobs(), 3)
+	assert.Equal(t, 3, s.Stats().Pending)
+	for _, n := range s.Occupancy() {
+		assert.Zero(t, n)
+	}
+
+	// They cascade into the main wheel ahead of their tick and run on time
+	s.Advance(10 * time.Second)
+	assert.Equal(t, []time.Duration{time.Second, 5 * time.Second}, fired)
+
+	s.Advance(time.Minute)
+	assert.Equal(t, []time.Duration{time.Second, 5 * time.Second, time.Minute}, fired)
+	assert.Empty(t, s.Jobs())
+}
+
+func TestRunAfterCoarseSeek(t *testing.T) {
+	s := newScheduler(time.Unix(0, 0))
+
+	var count Counter
+	s.RunAfterCoarse(count.Inc(), 30*time.Second, 0)
+	s.RunAfterCoarse(count.Inc(), 90*time.Second, 0)
+
+	// The skipped coarse ticks are cascaded when seeking
+	s.Seek(time.Unix(60, 0))
+	s.Tick()
+	assert.Equal(t, 1, count.Value())
+	assert.Len(t, s.Jobs(), 1)
+
+	// The clone keeps the coarse-grained jobs, and can be cancelled
+	clone := s.Clone()
+	assert.Len(t, clone.Jobs(), 1)
+	assert.Equal(t, 1, clone.CancelWhere(func(JobInfo) bool { return true }))
+
+	s.Advance(31 * time.Second)
+	assert.Equal(t, 2, count.Value())
+}
+
+func TestCoarsen(t *testing.T) {
+	s := newScheduler(time.Unix(0, 0))
+	assert.Equal(t, tick(10), s.coarsen(1, 0))
+	assert.Equal(t, tick(10), s.coarsen(10, 0))
+	assert.Equal(t, tick(7), s.coarsen(7, time.Millisecond))
+	assert.Equal(t, tick(100), s.coarsen(42, time.Second))
+}
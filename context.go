@@ -0,0 +1,46 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"context"
+	"time"
+)
+
+// TaskCtx defines a scheduled function which receives a context. The context carries
+// a deadline derived from the tick budget of the scheduler and is cancelled as soon as
+// the task returns, so that long running tasks can cooperatively abort.
+type TaskCtx = func(ctx context.Context, now time.Time, elapsed time.Duration) bool
+
+// RunCtx schedules a task for the next tick. The task receives a context which expires
+// once the tick budget is exceeded, given by WithBudget and defaulting to the resolution
+// of the clock. The deadline is based on the wall clock at the time the task starts.
+func (s *Scheduler) RunCtx(task TaskCtx) {
+	s.Run(s.withContext(task))
+}
+
+// withContext converts a task with a context into a regular task
+func (s *Scheduler) withContext(task TaskCtx) Task {
+	return func(now time.Time, elapsed time.Duration) bool {
+		ctx, cancel := context.WithTimeout(NewContext(context.Background(), s), s.budget)
+		defer cancel()
+		return task(ctx, now, elapsed)
+	}
+}
+
+// contextKey is the key of the scheduler carried by a context
+type contextKey struct{}
+
+// NewContext returns a copy of the context which carries the scheduler, so that it can be
+// retrieved with FromContext deep in a call stack. The context of a task scheduled with
+// RunCtx already carries the scheduler running it.
+func NewContext(ctx context.Context, s *Scheduler) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// FromContext returns the scheduler carried by the context, if any.
+func FromContext(ctx context.Context) (*Scheduler, bool) {
+	s, ok := ctx.Value(contextKey{}).(*Scheduler)
+	return s, ok
+}
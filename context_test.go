@@ -0,0 +1,69 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunCtx(t *testing.T) {
+	now := time.Unix(0, 0)
+	var captured context.Context
+
+	s := newScheduler(now, WithBudget(50*time.Millisecond))
+	s.RunCtx(func(ctx context.Context, now time.Time, elapsed time.Duration) bool {
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(50*time.Millisecond), deadline, 20*time.Millisecond)
+		assert.NoError(t, ctx.Err())
+		captured = ctx
+		return true
+	})
+
+	s.Tick()
+	assert.NotNil(t, captured)
+	assert.Error(t, captured.Err())
+}
+
+func TestRunCtxOverrun(t *testing.T) {
+	now := time.Unix(0, 0)
+	aborted := false
+
+	s := newScheduler(now)
+	s.RunCtx(func(ctx context.Context, now time.Time, elapsed time.Duration) bool {
+		select {
+		case <-ctx.Done():
+			aborted = true
+		case <-time.After(time.Second):
+		}
+		return true
+	})
+
+	s.Tick()
+	assert.True(t, aborted)
+}
+
+func TestFromContext(t *testing.T) {
+	_, ok := FromContext(context.Background())
+	assert.False(t, ok)
+
+	s := newScheduler(time.Unix(0, 0))
+	v, ok := FromContext(NewContext(context.Background(), s))
+	assert.True(t, ok)
+	assert.Equal(t, s, v)
+
+	// The context of a task carries the scheduler running it
+	var found *Scheduler
+	s.RunCtx(func(ctx context.Context, now time.Time, elapsed time.Duration) bool {
+		found, _ = FromContext(ctx)
+		return true
+	})
+
+	s.Tick()
+	assert.Same(t, s, found)
+}
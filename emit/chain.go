@@ -0,0 +1,72 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package emit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kelindar/event"
+)
+
+// publishers contains a publishing function for each subscribed event type, so that an
+// event only known as an event.Event can still be published with its concrete type.
+var publishers sync.Map // map[uint32]func(event.Event, time.Time, time.Duration)
+
+// OnChain subscribes to an event and schedules the follow-up event returned by the handler
+// to be written after the returned 'delay'. This allows to describe event-driven state
+// machines declaratively. Returning a nil event stops the chain.
+func OnChain[T event.Event](handler func(ev T, now time.Time) (next event.Event, delay time.Duration, err error)) context.CancelFunc {
+	return On(func(ev T, now time.Time, _ time.Duration) error {
+		next, delay, err := handler(ev, now)
+		if next != nil {
+			Scheduler.RunAfter(emitAny(next), delay)
+		}
+		return err
+	})
+}
+
+// register registers the publishing function of the event type
+func register[T event.Event](eventType uint32) {
+	if _, ok := publishers.Load(eventType); ok {
+		return
+	}
+
+	publishers.LoadOrStore(eventType, func(ev event.Event, now time.Time, elapsed time.Duration) {
+		data, ok := ev.(T)
+		if !ok {
+			Error(fmt.Errorf("emit: event type 0x%x is subscribed as %T, not %T", eventType, data, ev), ev)
+			return
+		}
+
+		publish(signal[T]{
+			Data:    data,
+			Time:    now,
+			Elapsed: elapsed,
+			At:      now,
+		})
+	})
+}
+
+// emitAny writes an event of an unknown concrete type into the dispatcher. Since nobody
+// can receive an event whose type was never subscribed to, such event is unhandled.
+func emitAny(ev event.Event) func(now time.Time, elapsed time.Duration) bool {
+	return func(now time.Time, elapsed time.Duration) bool {
+		publishAny(ev, now, elapsed)
+		return true
+	}
+}
+
+// publishAny writes an event of an unknown concrete type into the dispatcher
+func publishAny(ev event.Event, now time.Time, elapsed time.Duration) {
+	fn, ok := publishers.Load(ev.Type())
+	switch {
+	case ok:
+		fn.(func(event.Event, time.Time, time.Duration))(ev, now, elapsed)
+	case handled(ev.Type(), ev):
+		fanOut(signal[event.Event]{Data: ev, Time: now, Elapsed: elapsed})
+	}
+}
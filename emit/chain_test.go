@@ -0,0 +1,55 @@
+package emit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kelindar/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnChain(t *testing.T) {
+	lines := make(chan string, 4)
+	defer OnChain(func(ev Line, now time.Time) (event.Event, time.Duration, error) {
+		lines <- ev.Text
+		switch ev.Text {
+		case "hello":
+			return Line{Text: "how are you?"}, 20 * time.Millisecond, nil
+		case "how are you?":
+			return Answer{Text: "fine"}, 0, nil
+		default:
+			return nil, 0, nil
+		}
+	})()
+
+	answers := make(chan string, 1)
+	defer On(func(ev Answer, now time.Time, elapsed time.Duration) error {
+		answers <- ev.Text
+		return nil
+	})()
+
+	Next(Line{Text: "hello"})
+	assert.Equal(t, "hello", <-lines)
+	assert.Equal(t, "how are you?", <-lines)
+	assert.Equal(t, "fine", <-answers)
+}
+
+func TestOnChainUnsubscribed(t *testing.T) {
+	assert.NotPanics(t, func() {
+		emitAny(Dynamic{ID: 47})(time.Now(), 0)
+	})
+}
+
+// ------------------------------------- Test Events -------------------------------------
+
+type Line struct {
+	Text string
+}
+
+func (Line) Type() uint32 { return 0x108 }
+
+type Answer struct {
+	Text string
+}
+
+func (Answer) Type() uint32 { return 0x109 }
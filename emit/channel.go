@@ -0,0 +1,52 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package emit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kelindar/event"
+)
+
+// Delivery represents an event delivered on a channel, along with its timing.
+type Delivery[T event.Event] struct {
+	Data    T             // The event itself
+	Time    time.Time     // The time at which the event was emitted
+	Elapsed time.Duration // The time elapsed since the last event
+}
+
+// Channel subscribes to an event and returns a channel on which the events of type T are
+// delivered, with room for 'buffer' pending events. The events are never delivered while
+// the consumer is lagging behind: when the channel is full, the new events are dropped.
+// Cancelling the subscription unsubscribes and closes the channel.
+func Channel[T event.Event](buffer int) (<-chan Delivery[T], context.CancelFunc) {
+	var mu sync.Mutex
+	var done bool
+	ch := make(chan Delivery[T], buffer)
+	cancel := On(func(ev T, now time.Time, elapsed time.Duration) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if done {
+			return nil
+		}
+
+		select {
+		case ch <- Delivery[T]{Data: ev, Time: now, Elapsed: elapsed}:
+		default: // the channel is full, drop the event
+		}
+		return nil
+	})
+
+	return ch, func() {
+		cancel()
+		mu.Lock()
+		defer mu.Unlock()
+		if !done {
+			done = true
+			close(ch)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package emit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Reading struct {
+	Text string
+}
+
+func (Reading) Type() uint32 { return 0x10a }
+
+func TestChannel(t *testing.T) {
+	events, cancel := Channel[Reading](1)
+
+	Next(Reading{Text: "1"})
+	msg := <-events
+	assert.Equal(t, "1", msg.Data.Text)
+	assert.False(t, msg.Time.IsZero())
+
+	// The events are dropped while the channel is full
+	NextBatch([]Reading{{Text: "2"}, {Text: "3"}, {Text: "4"}})
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "2", (<-events).Data.Text)
+
+	// Cancelling closes the channel
+	cancel()
+	cancel()
+	_, ok := <-events
+	assert.False(t, ok)
+}
@@ -0,0 +1,51 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package emit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kelindar/event"
+)
+
+// coalesced contains the latest coalesced events which are pending delivery, per key
+var coalesced struct {
+	sync.Mutex
+	pending map[coalesceKey]event.Event
+}
+
+// coalesceKey represents the key of a coalesced event, within its event type
+type coalesceKey struct {
+	eventType uint32
+	key       uint64
+}
+
+// NextCoalesced writes an event during the next tick, similarly to Next, but delivers at most
+// one event of the same type and 'key' per tick. If the event is written several times before
+// the next tick, only the most recent one is delivered. This is useful for idempotent
+// notifications, reducing the number of times the handlers are invoked.
+func NextCoalesced[T event.Event](ev T, key uint64) {
+	k := coalesceKey{eventType: ev.Type(), key: key}
+	coalesced.Lock()
+	if coalesced.pending == nil {
+		coalesced.pending = make(map[coalesceKey]event.Event)
+	}
+
+	_, scheduled := coalesced.pending[k]
+	coalesced.pending[k] = ev
+	coalesced.Unlock()
+	if scheduled {
+		return
+	}
+
+	due := Scheduler.Now()
+	once(due, 0, func(now time.Time, elapsed time.Duration) bool {
+		coalesced.Lock()
+		latest := coalesced.pending[k].(T)
+		delete(coalesced.pending, k)
+		coalesced.Unlock()
+		return emit(latest, due)(now, elapsed)
+	})
+}
@@ -0,0 +1,38 @@
+package emit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextCoalesced(t *testing.T) {
+	var received []Changed
+	defer On(func(ev Changed, now time.Time, elapsed time.Duration) error {
+		received = append(received, ev)
+		return nil
+	})()
+
+	for i := 1; i <= 100; i++ {
+		NextCoalesced(Changed{Key: 1, Version: i}, 1)
+		NextCoalesced(Changed{Key: 2, Version: i}, 2)
+	}
+
+	// The most recent event of every key is delivered
+	assert.NoError(t, Flush(context.Background()))
+	assert.Less(t, len(received), 200)
+
+	latest := map[int]int{}
+	for _, ev := range received {
+		latest[ev.Key] = ev.Version
+	}
+	assert.Equal(t, map[int]int{1: 100, 2: 100}, latest)
+}
+
+type Changed struct {
+	Key, Version int
+}
+
+func (Changed) Type() uint32 { return 0x10d }
@@ -0,0 +1,147 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+// Package emittest provides utilities for testing the code built on the emit package
+// deterministically, on a virtual clock rather than the wall clock.
+package emittest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kelindar/event"
+	"github.com/kelindar/timeline"
+	"github.com/kelindar/timeline/emit"
+)
+
+// Clock represents a virtual clock which drives the emit package in place of its default
+// scheduler, so that the events are only written when the clock is advanced.
+type Clock struct {
+	scheduler *timeline.Scheduler
+	previous  *timeline.Scheduler
+}
+
+// NewClock swaps the scheduler of the emit package with a virtual one, starting at 'start',
+// and returns the clock driving it. The clock must be closed once the test is done, which
+// restores the previous scheduler. Since the scheduler is global, the tests using a virtual
+// clock must not run in parallel.
+func NewClock(start time.Time) *Clock {
+	s := timeline.New()
+	s.Seek(start)
+
+	c := &Clock{
+		scheduler: s,
+		previous:  emit.Scheduler,
+	}
+
+	emit.Scheduler = s
+	return c
+}
+
+// Now returns the current time of the virtual clock.
+func (c *Clock) Now() time.Time {
+	return c.scheduler.Now()
+}
+
+// Advance moves the virtual clock forward by 'd', rounded down to the resolution of the
+// clock. Every intervening tick is processed and the events written during a tick are
+// delivered to the subscribers before the next tick, so that the events emitted by the
+// handlers are processed in order. It returns the new time of the clock.
+func (c *Clock) Advance(d time.Duration) time.Time {
+	for i := d / c.scheduler.Resolution(); i > 0; i-- {
+		c.scheduler.Tick()
+		emit.Drain(context.Background())
+	}
+
+	return c.scheduler.Now()
+}
+
+// Close restores the scheduler of the emit package which was replaced by the clock. The
+// events which are still scheduled on the virtual clock are cancelled and never written, so
+// that they are not waited for by emit.Flush.
+func (c *Clock) Close() {
+	c.scheduler.CancelWhere(func(timeline.JobInfo) bool { return true })
+	emit.Flush(context.Background())
+	emit.Scheduler = c.previous
+}
+
+// ----------------------------------------- Recorder -----------------------------------------
+
+// Record represents an event received by a recorder.
+type Record struct {
+	Event   event.Event   // The received event
+	Now     time.Time     // The time at which the event was emitted
+	Elapsed time.Duration // The elapsed time received along with the event
+}
+
+// Recorder represents a subscriber which records the events it receives. It is safe for
+// concurrent use.
+type Recorder struct {
+	mu      sync.Mutex
+	records []Record
+	cancel  []context.CancelFunc
+}
+
+// NewRecorder returns a new recorder, which subscribes to the event types with Watch.
+func NewRecorder() *Recorder {
+	return new(Recorder)
+}
+
+// Watch subscribes the recorder to the events of type T.
+func Watch[T event.Event](r *Recorder) {
+	cancel := emit.On(func(ev T, now time.Time, elapsed time.Duration) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.records = append(r.records, Record{
+			Event:   ev,
+			Now:     now,
+			Elapsed: elapsed,
+		})
+		return nil
+	})
+
+	r.mu.Lock()
+	r.cancel = append(r.cancel, cancel)
+	r.mu.Unlock()
+}
+
+// Records returns a copy of the records received so far, in the order they were received.
+func (r *Recorder) Records() []Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Record(nil), r.records...)
+}
+
+// Events returns the events of type T received so far by the recorder, in order.
+func Events[T event.Event](r *Recorder) []T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []T
+	for _, rec := range r.records {
+		if ev, ok := rec.Event.(T); ok {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+// Reset clears the records received so far.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = r.records[:0]
+}
+
+// Close unsubscribes the recorder from all of the event types it watches.
+func (r *Recorder) Close() {
+	r.mu.Lock()
+	cancel := r.cancel
+	r.cancel = nil
+	r.mu.Unlock()
+
+	for _, fn := range cancel {
+		fn()
+	}
+}
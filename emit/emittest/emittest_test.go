@@ -0,0 +1,66 @@
+package emittest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kelindar/timeline/emit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClock(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := NewClock(start)
+	defer clock.Close()
+
+	rec := NewRecorder()
+	defer rec.Close()
+	Watch[Ping](rec)
+	Watch[Pong](rec)
+
+	// Reply to every ping on the next tick
+	defer emit.On(func(ev Ping, now time.Time, elapsed time.Duration) error {
+		emit.Next(Pong{ID: ev.ID})
+		return nil
+	})()
+
+	emit.Next(Ping{ID: 1})
+	emit.After(Ping{ID: 2}, 50*time.Millisecond)
+	assert.Empty(t, rec.Records())
+
+	clock.Advance(20 * time.Millisecond)
+	assert.Equal(t, []Record{
+		{Event: Ping{ID: 1}, Now: start},
+		{Event: Pong{ID: 1}, Now: start.Add(10 * time.Millisecond)},
+	}, rec.Records())
+
+	rec.Reset()
+	assert.Equal(t, start.Add(70*time.Millisecond), clock.Advance(50*time.Millisecond))
+	assert.Equal(t, []Ping{{ID: 2}}, Events[Ping](rec))
+	assert.Equal(t, []Pong{{ID: 2}}, Events[Pong](rec))
+}
+
+func TestClockClose(t *testing.T) {
+	previous := emit.Scheduler
+	clock := NewClock(time.Unix(0, 0))
+	assert.NotSame(t, previous, emit.Scheduler)
+	assert.Equal(t, time.Unix(0, 0), clock.Now())
+
+	// The events still scheduled on the virtual clock are cancelled
+	emit.After(Ping{}, time.Second)
+	clock.Close()
+	assert.Same(t, previous, emit.Scheduler)
+	assert.Zero(t, clock.scheduler.Stats().Pending)
+}
+
+type Ping struct {
+	ID int
+}
+
+func (Ping) Type() uint32 { return 0x1 }
+
+type Pong struct {
+	ID int
+}
+
+func (Pong) Type() uint32 { return 0x2 }
@@ -5,7 +5,9 @@ package emit
 
 import (
 	"context"
+	"fmt"
 	"math"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -27,10 +29,21 @@ type signal[T event.Event] struct {
 	Time    time.Time     // The time at which the event was emitted
 	Elapsed time.Duration // The time elapsed since the last event
 	Data    T
+	At      time.Time // The time of the scheduler the event was scheduled for
+	barrier *barrier  // The barrier written by Flush, nil for the actual events
+}
+
+// stale returns whether the event is delivered more than 'age' after the time it was scheduled
+// for, on the clock of the scheduler
+func (e signal[T]) stale(age time.Duration) bool {
+	return age > 0 && !e.At.IsZero() && Scheduler.Now().Sub(e.At) > age
 }
 
 // Type returns the type of the event
 func (e signal[T]) Type() uint32 {
+	if e.barrier != nil {
+		return e.barrier.typ
+	}
 	return e.Data.Type()
 }
 
@@ -49,8 +62,15 @@ func (e fault) Type() uint32 {
 
 // ----------------------------------------- Timer Event -----------------------------------------
 
+// Timers are within [1<<30, 1<<31), the upper half is reserved for namespaces
 var nextTimerID uint32 = 1 << 30
 
+// timers contains the released timer identifiers which can be reused
+var timers struct {
+	sync.Mutex
+	free []uint32
+}
+
 // Timer represents a Timer event
 type Timer struct {
 	ID uint32
@@ -65,21 +85,56 @@ func (e Timer) Type() uint32 {
 
 // On subscribes to an event, the type of the event will be automatically
 // inferred from the provided type. Must be constant for this to work.
-func On[T event.Event](handler func(event T, now time.Time, elapsed time.Duration) error) context.CancelFunc {
-	return event.Subscribe[signal[T]](event.Default, func(m signal[T]) {
-		if err := handler(m.Data, m.Time, m.Elapsed); err != nil {
-			Error(err, m.Data)
-		}
-	})
+func On[T event.Event](handler func(event T, now time.Time, elapsed time.Duration) error, options ...Option) context.CancelFunc {
+	var ev T
+	return OnType(ev.Type(), handler, options...)
 }
 
 // OnType subscribes to an event with the specified event type.
-func OnType[T event.Event](eventType uint32, handler func(event T, now time.Time, elapsed time.Duration) error) context.CancelFunc {
-	return event.SubscribeTo[signal[T]](event.Default, eventType, func(m signal[T]) {
-		if err := handler(m.Data, m.Time, m.Elapsed); err != nil {
-			Error(err, m.Data)
+func OnType[T event.Event](eventType uint32, handler func(event T, now time.Time, elapsed time.Duration) error, options ...Option) context.CancelFunc {
+	register[T](eventType)
+	untrack := track(eventType)
+	age := configOf(options).maxAge
+	handler, remove := listen(eventType, recovered(configure(handlerOf(handler), options)))
+	self := flushable[T](event.Default, eventType)
+	cancel := event.SubscribeTo[signal[T]](event.Default, eventType, func(m signal[T]) {
+		switch {
+		case m.barrier == nil && m.stale(age):
+			Error(ErrStale, m.Data)
+		case m.barrier == nil:
+			if err := handler(m.Data, m.Time, m.Elapsed); err != nil {
+				Error(err, m.Data)
+			}
+		case m.barrier.owner == self:
+			close(m.barrier.done)
 		}
 	})
+
+	unflush := self.enable()
+	return func() {
+		untrack()
+		unflush()
+		remove()
+		cancel()
+	}
+}
+
+// recovered converts a panic of the handler into an error, so that it is reported through
+// OnError rather than taking down the dispatcher and the other subscribers.
+func recovered[T event.Event](handler func(T, time.Time, time.Duration) error) func(T, time.Time, time.Duration) error {
+	return func(ev T, now time.Time, elapsed time.Duration) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = panicked(ev.Type(), r)
+			}
+		}()
+		return handler(ev, now, elapsed)
+	}
+}
+
+// panicked returns the error which a panic of a handler of the event type is converted to
+func panicked(eventType uint32, r any) error {
+	return fmt.Errorf("emit: handler of event type 0x%x panicked: %v", eventType, r)
 }
 
 // OnError subscribes to an error event.
@@ -91,69 +146,214 @@ func OnError(handler func(err error, about any)) context.CancelFunc {
 
 // OnEvery creates a timer that fires every 'interval' and calls the handler.
 func OnEvery(handler func(now time.Time, elapsed time.Duration) error, interval time.Duration) context.CancelFunc {
-	id := atomic.AddUint32(&nextTimerID, 1)
-	if id >= (math.MaxUint32 - 1) {
-		panic("emit: too many timers created")
-	}
+	id := acquireTimer()
 
 	// Subscribe to the timer event
 	cancel := OnType[Timer](id, func(_ Timer, now time.Time, elapsed time.Duration) error {
 		return handler(now, elapsed)
 	})
 
-	// Start the timer
-	Every(Timer{ID: id}, interval)
-	return cancel
+	// Start the timer, the identifier is only released once the timer has stopped so
+	// that a reused identifier never receives an event of the previous timer.
+	var stop atomic.Bool
+	publish := emit(Timer{ID: id}, time.Time{})
+	Scheduler.RunEvery(func(now time.Time, elapsed time.Duration) bool {
+		if stop.Load() {
+			releaseTimer(id)
+			return false
+		}
+		return publish(now, elapsed)
+	}, interval)
+
+	return func() {
+		cancel()
+		stop.Store(true)
+	}
+}
+
+// acquireTimer returns an unused timer identifier
+func acquireTimer() uint32 {
+	timers.Lock()
+	defer timers.Unlock()
+	if n := len(timers.free); n > 0 {
+		id := timers.free[n-1]
+		timers.free = timers.free[:n-1]
+		return id
+	}
+
+	if nextTimerID+1 >= namespaceBase {
+		panic("emit: too many timers created")
+	}
+
+	nextTimerID++
+	return nextTimerID
+}
+
+// releaseTimer releases the timer identifier so it can be reused
+func releaseTimer(id uint32) {
+	timers.Lock()
+	defer timers.Unlock()
+	timers.free = append(timers.free, id)
 }
 
 // ----------------------------------------- Publish -----------------------------------------
 
-// Next writes an event during the next tick.
+// Delayed represents an event which is always delivered with a delay, so that the timing
+// policy is kept with the event definition rather than with every call site.
+type Delayed interface {
+	Delay() time.Duration
+}
+
+// Next writes an event during the next tick. If the event implements Delayed with a positive
+// delay, it is written after that delay instead, similarly to After.
 func Next[T event.Event](ev T) {
-	Scheduler.Run(emit(ev))
+	if d, ok := any(ev).(Delayed); ok {
+		if delay := d.Delay(); delay > 0 {
+			After(ev, delay)
+			return
+		}
+	}
+
+	priority, _ := priorityOf(ev.Type())
+	now := Scheduler.Now()
+	once(now, priority, emit(ev, now))
+}
+
+// NextWithTime writes an event during the next tick, similarly to Next, but the handlers
+// receive the provided time 't' rather than the time of the tick. This can be used to
+// replay recorded events with their original timestamps.
+func NextWithTime[T event.Event](ev T, t time.Time) {
+	priority, _ := priorityOf(ev.Type())
+	due := Scheduler.Now()
+	once(due, priority, func(_ time.Time, elapsed time.Duration) bool {
+		publish(signal[T]{
+			Data:    ev,
+			Time:    t,
+			Elapsed: elapsed,
+			At:      due,
+		})
+		return true
+	})
+}
+
+// NextBatch writes a batch of events during the next tick. The events are scheduled as a
+// single task, amortizing the scheduling overhead, but are still delivered individually.
+// The events are copied, so the slice can be reused once the call returns.
+func NextBatch[T event.Event](evs []T) {
+	evs = append([]T(nil), evs...)
+	now := Scheduler.Now()
+	once(now, 0, emitBatch(evs, now))
+}
+
+// NextAll writes several events of possibly different types during the next tick, in order.
+// The events are written by a single task, so that no other scheduled event is written in
+// between them and the subscribers observe them as a consistent batch. The handlers of the
+// different event types are still invoked independently of each other.
+func NextAll(evs ...event.Event) {
+	evs = append([]event.Event(nil), evs...)
+	once(time.Time{}, 0, func(now time.Time, elapsed time.Duration) bool {
+		for _, ev := range evs {
+			publishAny(ev, now, elapsed)
+		}
+		return true
+	})
 }
 
 // At writes an event at specific 'at' time.
 func At[T event.Event](ev T, at time.Time) {
-	Scheduler.RunAt(emit(ev), at)
+	priority, _ := priorityOf(ev.Type())
+	once(at, priority, emit(ev, at))
 }
 
 // After writes an event after a 'delay'.
 func After[T event.Event](ev T, after time.Duration) {
-	Scheduler.RunAfter(emit(ev), after)
+	priority, _ := priorityOf(ev.Type())
+	at := Scheduler.Now().Add(after)
+	once(at, priority, emit(ev, at))
 }
 
 // Every writes an event at 'interval' intervals, starting at the next boundary tick.
 func Every[T event.Event](ev T, interval time.Duration) {
-	Scheduler.RunEvery(emit(ev), interval)
+	Scheduler.RunEvery(emit(ev, time.Time{}), interval)
+}
+
+// EveryWhile writes an event at 'interval' intervals, starting at the next boundary tick, for
+// as long as the 'cond' predicate returns true. The predicate is evaluated on the scheduler
+// goroutine before each emission. It returns a cancel function to stop early regardless.
+func EveryWhile[T event.Event](ev T, interval time.Duration, cond func() bool) context.CancelFunc {
+	var stop atomic.Bool
+	publish := emit(ev, time.Time{})
+	Scheduler.RunEvery(func(now time.Time, elapsed time.Duration) bool {
+		if stop.Load() || !cond() {
+			return false
+		}
+		return publish(now, elapsed)
+	}, interval)
+
+	return func() {
+		stop.Store(true)
+	}
 }
 
 // EveryAt writes an event at 'interval' intervals, starting at 'startTime'.
 func EveryAt[T event.Event](ev T, interval time.Duration, startTime time.Time) {
-	Scheduler.RunEveryAt(emit(ev), interval, startTime)
+	Scheduler.RunEveryAt(emit(ev, time.Time{}), interval, startTime)
 }
 
 // EveryAfter writes an event at 'interval' intervals after a 'delay'.
 func EveryAfter[T event.Event](ev T, interval time.Duration, delay time.Duration) {
-	Scheduler.RunEveryAfter(emit(ev), interval, delay)
+	Scheduler.RunEveryAfter(emit(ev, time.Time{}), interval, delay)
 }
 
-// Error writes an error event.
+// Error writes an error event. The identical errors are coalesced, see SetErrorWindow.
 func Error(err error, about any) {
+	if throttle(err, about) {
+		report(err, about)
+	}
+}
+
+// report logs and writes an error event
+func report(err error, about any) {
+	logError(err, about)
 	event.Publish(event.Default, fault{
 		error: err,
 		About: about,
 	})
 }
 
-// emit writes an event into the dispatcher
-func emit[T event.Event](ev T) func(now time.Time, elapsed time.Duration) bool {
+// emit writes an event into the dispatcher. The event is written at 'due', the time it was
+// scheduled for, or at the tick it runs on if zero, see MaxAge.
+func emit[T event.Event](ev T, due time.Time) func(now time.Time, elapsed time.Duration) bool {
 	return func(now time.Time, elapsed time.Duration) bool {
-		event.Publish(event.Default, signal[T]{
+		publish(signal[T]{
 			Data:    ev,
 			Time:    now,
 			Elapsed: elapsed,
+			At:      dueOf(due, now),
 		})
 		return true
 	}
 }
+
+// emitBatch writes a batch of events into the dispatcher, similarly to emit
+func emitBatch[T event.Event](evs []T, due time.Time) func(now time.Time, elapsed time.Duration) bool {
+	return func(now time.Time, elapsed time.Duration) bool {
+		for _, ev := range evs {
+			publish(signal[T]{
+				Data:    ev,
+				Time:    now,
+				Elapsed: elapsed,
+				At:      dueOf(due, now),
+			})
+		}
+		return true
+	}
+}
+
+// dueOf returns the time an event was scheduled for, or the tick it runs on if unknown
+func dueOf(due, now time.Time) time.Time {
+	if due.IsZero() {
+		return now
+	}
+	return due
+}
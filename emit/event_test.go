@@ -50,6 +50,43 @@ func BenchmarkEvent(b *testing.B) {
 	}
 }
 
+/*
+cpu: Intel(R) Xeon(R) Processor
+BenchmarkBatch/next         	   48619	     23572 ns/op	   13991 B/op	     100 allocs/op
+BenchmarkBatch/batch        	 6198438	       311.4 ns/op	     184 B/op	       1 allocs/op
+*/
+func BenchmarkBatch(b *testing.B) {
+	const size = 100
+	batch := make([]Dynamic, size)
+	for i := range batch {
+		batch[i] = Dynamic{ID: 20}
+	}
+
+	var count atomic.Int64
+	defer OnType(20, func(ev Dynamic, now time.Time, elapsed time.Duration) error {
+		count.Add(1)
+		return nil
+	})()
+
+	b.Run("next", func(b *testing.B) {
+		b.ReportAllocs()
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			for i := 0; i < size; i++ {
+				Next(batch[i])
+			}
+		}
+	})
+
+	b.Run("batch", func(b *testing.B) {
+		b.ReportAllocs()
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			NextBatch(batch)
+		}
+	})
+}
+
 func TestEmit(t *testing.T) {
 	events := make(chan MyEvent2)
 	defer On(func(ev MyEvent2, now time.Time, elapsed time.Duration) error {
@@ -78,6 +115,117 @@ func TestEmit(t *testing.T) {
 	<-events
 }
 
+func TestNextDelayed(t *testing.T) {
+	events := make(chan time.Duration, 2)
+	defer On(func(ev Saved, now time.Time, elapsed time.Duration) error {
+		events <- now.Sub(ev.At)
+		return nil
+	})()
+
+	// Delivered after the delay declared by the event
+	Next(Saved{At: Scheduler.Now(), After: 100 * time.Millisecond})
+	assert.GreaterOrEqual(t, <-events, 100*time.Millisecond)
+
+	// Delivered on the next tick without a delay
+	Next(Saved{At: Scheduler.Now()})
+	assert.Less(t, <-events, 100*time.Millisecond)
+}
+
+func TestNextBatch(t *testing.T) {
+	events := make(chan Dynamic, 3)
+	defer OnType(43, func(ev Dynamic, now time.Time, elapsed time.Duration) error {
+		events <- ev
+		return nil
+	})()
+
+	NextBatch([]Dynamic{{ID: 43}, {ID: 43}, {ID: 43}})
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, 43, (<-events).ID)
+	}
+
+	// The batch is copied, so reusing the slice does not change the events
+	batch := []Dynamic{{ID: 43}, {ID: 43}, {ID: 43}}
+	NextBatch(batch)
+	batch[0].ID = 0
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, 43, (<-events).ID)
+	}
+}
+
+func TestNextAll(t *testing.T) {
+	lines := make(chan time.Time, 2)
+	defer On(func(ev Line, now time.Time, elapsed time.Duration) error {
+		lines <- now
+		return nil
+	})()
+
+	answers := make(chan time.Time, 1)
+	defer On(func(ev Answer, now time.Time, elapsed time.Duration) error {
+		answers <- now
+		return nil
+	})()
+
+	// All of the events are written during the same tick
+	NextAll(Line{Text: "a"}, Answer{Text: "b"}, Line{Text: "c"})
+	at := <-answers
+	assert.Equal(t, at, <-lines)
+	assert.Equal(t, at, <-lines)
+}
+
+func TestNextWithTime(t *testing.T) {
+	events := make(chan time.Time, 1)
+	defer OnType(51, func(ev Dynamic, now time.Time, elapsed time.Duration) error {
+		events <- now
+		return nil
+	})()
+
+	at := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	NextWithTime(Dynamic{ID: 51}, at)
+	assert.Equal(t, at, <-events)
+}
+
+func TestEveryWhile(t *testing.T) {
+	var count atomic.Int32
+	defer OnType(44, func(ev Dynamic, now time.Time, elapsed time.Duration) error {
+		count.Add(1)
+		return nil
+	})()
+
+	// Emit while connected
+	var connected atomic.Bool
+	connected.Store(true)
+	EveryWhile(Dynamic{ID: 44}, 10*time.Millisecond, connected.Load)
+	assert.Eventually(t, func() bool {
+		return count.Load() >= 3
+	}, time.Second, time.Millisecond)
+
+	// Disconnect, the heartbeat should stop
+	connected.Store(false)
+	time.Sleep(50 * time.Millisecond)
+	stopped := count.Load()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, stopped, count.Load())
+}
+
+func TestEveryWhileCancel(t *testing.T) {
+	var count atomic.Int32
+	defer OnType(45, func(ev Dynamic, now time.Time, elapsed time.Duration) error {
+		count.Add(1)
+		return nil
+	})()
+
+	cancel := EveryWhile(Dynamic{ID: 45}, 10*time.Millisecond, func() bool { return true })
+	assert.Eventually(t, func() bool {
+		return count.Load() >= 1
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	stopped := count.Load()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, stopped, count.Load())
+}
+
 func TestOnType(t *testing.T) {
 	events := make(chan Dynamic)
 	defer OnType(42, func(ev Dynamic, now time.Time, elapsed time.Duration) error {
@@ -126,6 +274,27 @@ func TestOnTypeError(t *testing.T) {
 	assert.Equal(t, "OnType()", (<-errors).Error())
 }
 
+func TestOnPanic(t *testing.T) {
+	errors := make(chan any, 1)
+	defer OnError(func(err error, about any) {
+		errors <- about
+	})()
+
+	// The first subscriber panics, the second one still receives the event
+	events := make(chan int, 1)
+	defer OnType(52, func(ev Dynamic, now time.Time, elapsed time.Duration) error {
+		panic("boom")
+	})()
+	defer OnType(52, func(ev Dynamic, now time.Time, elapsed time.Duration) error {
+		events <- ev.ID
+		return nil
+	})()
+
+	Next(Dynamic{ID: 52})
+	assert.Equal(t, 52, <-events)
+	assert.Equal(t, Dynamic{ID: 52}, <-errors)
+}
+
 func TestOnEvery(t *testing.T) {
 	events := make(chan MyEvent2)
 	defer OnEvery(func(now time.Time, elapsed time.Duration) error {
@@ -141,13 +310,46 @@ func TestOnEvery(t *testing.T) {
 
 func TestTooManyTimers(t *testing.T) {
 	assert.Panics(t, func() {
+		timers.Lock()
 		nextTimerID = math.MaxUint32 - 1
+		timers.free = nil
+		timers.Unlock()
 		defer OnEvery(func(now time.Time, elapsed time.Duration) error {
 			return nil
 		}, 200*time.Millisecond)()
 	})
 }
 
+func TestTimerRecycle(t *testing.T) {
+	timers.Lock()
+	nextTimerID = 1 << 30
+	timers.Unlock()
+
+	for i := 0; i < 10; i++ {
+		cancels := make([]func(), 0, 10)
+		for j := 0; j < 10; j++ {
+			cancels = append(cancels, OnEvery(func(now time.Time, elapsed time.Duration) error {
+				return nil
+			}, 10*time.Millisecond))
+		}
+
+		for _, cancel := range cancels {
+			cancel()
+		}
+
+		// Wait for all of the timers to be stopped and released
+		assert.Eventually(t, func() bool {
+			timers.Lock()
+			defer timers.Unlock()
+			return len(timers.free) >= 10
+		}, time.Second, 5*time.Millisecond)
+	}
+
+	timers.Lock()
+	defer timers.Unlock()
+	assert.LessOrEqual(t, nextTimerID, uint32(1<<30+10))
+}
+
 // ------------------------------------- Test Events -------------------------------------
 
 const (
@@ -167,6 +369,15 @@ type MyEvent2 struct {
 
 func (t MyEvent2) Type() uint32 { return TypeEvent2 }
 
+// Saved represents an event which is delivered with a delay
+type Saved struct {
+	At    time.Time
+	After time.Duration
+}
+
+func (Saved) Type() uint32            { return 0x10f }
+func (ev Saved) Delay() time.Duration { return ev.After }
+
 type Dynamic struct {
 	ID int
 }
@@ -0,0 +1,195 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package emit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kelindar/event"
+	"github.com/kelindar/timeline"
+)
+
+// pending contains the one-shot events which are scheduled but were not written yet, per
+// scheduler, map[*timeline.Scheduler]*inflight
+var pending sync.Map
+
+// inflight counts the jobs of the one-shot events scheduled on a scheduler, until they are
+// done, rejected or cancelled. The jobs are counted in generations, so that a flush only waits
+// for the jobs which were scheduled before it.
+type inflight struct {
+	current atomic.Pointer[generation] // the generation counting the newly scheduled jobs
+	removed atomic.Bool                // whether the counter is removed from the pending ones
+}
+
+// generation counts the jobs scheduled between two flushes
+type generation struct {
+	count  atomic.Int64
+	prev   atomic.Pointer[generation] // the generation before it, until it is settled
+	settle func(timeline.State)       // decrements the counter once a job is settled
+}
+
+// newGeneration creates a new generation following the previous one, if any
+func newGeneration(prev *generation) *generation {
+	g := new(generation)
+	g.prev.Store(prev)
+	g.settle = func(timeline.State) { g.count.Add(-1) }
+	return g
+}
+
+// flushers contains the subscribers which can be flushed, map[*flusher]struct{}
+var flushers sync.Map
+
+// flusher represents a subscriber which can be flushed
+type flusher struct {
+	flush func(done chan struct{}) // writes a barrier for this subscriber
+}
+
+// barrier represents a marker written after the pending events of a subscriber, which is
+// not delivered to the handler but signals that all of the events before it were handled.
+type barrier struct {
+	owner *flusher
+	typ   uint32
+	done  chan struct{}
+}
+
+// Flush blocks until all of the events written with Next, NextBatch, At or After before
+// the call have been delivered, and the handlers registered with On, OnType, OnTypes or
+// OnOrdered have completed. The events scheduled at a later time on the current Scheduler
+// are waited for as well, unless they are rejected or cancelled, but not the events written
+// after the call. It returns the error of the context if it is done first.
+func Flush(ctx context.Context) error {
+	s := Scheduler
+	if v, ok := pending.Load(s); ok {
+		ticker := time.NewTicker(s.Resolution())
+		defer ticker.Stop()
+
+		// Wait for the events scheduled before the call to be written into the dispatcher
+		jobs := v.(*inflight)
+		next := jobs.rotate()
+		for g := next.prev.Load(); g != nil; g = g.prev.Load() {
+			for g.count.Load() > 0 {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-ticker.C:
+				}
+			}
+		}
+
+		next.prev.Store(nil) // the previous generations are settled
+		jobs.release(s)
+	}
+
+	return Drain(ctx)
+}
+
+// Drain blocks until the events already written into the dispatcher have been delivered, and
+// the handlers registered with On, OnType, OnTypes or OnOrdered have completed. Unlike Flush,
+// it does not wait for the events which are scheduled but not written yet. It returns the
+// error of the context if it is done first.
+func Drain(ctx context.Context) error {
+
+	// Write a barrier for every subscriber, which is received after the pending events
+	var pending []chan struct{}
+	flushers.Range(func(key, _ any) bool {
+		done := make(chan struct{})
+		pending = append(pending, done)
+		key.(*flusher).flush(done)
+		return true
+	})
+
+	for _, done := range pending {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-done:
+		}
+	}
+	return nil
+}
+
+// flushable returns the flusher of a subscriber of the event type on the dispatcher. It must
+// be registered with enable once the subscriber is subscribed, so that it receives the barrier.
+func flushable[T event.Event](dispatcher *event.Dispatcher, eventType uint32) *flusher {
+	f := new(flusher)
+	f.flush = func(done chan struct{}) {
+		event.Publish(dispatcher, signal[T]{
+			barrier: &barrier{owner: f, typ: eventType, done: done},
+		})
+	}
+	return f
+}
+
+// enable registers the flusher, so that its subscriber is flushed, and returns a function
+// which unregisters it
+func (f *flusher) enable() func() {
+	flushers.Store(f, struct{}{})
+	return func() {
+		flushers.Delete(f)
+	}
+}
+
+// once schedules the task writing one-shot events at 'at', and counts it as in-flight until
+// it is settled, so that it can be flushed.
+func once(at time.Time, priority uint8, task timeline.Task) {
+	s := Scheduler
+	if at.IsZero() {
+		at = s.Now()
+	}
+
+	jobs := inflightOf(s)
+	s.SchedulePriority(task, at, priority).OnSettled(jobs.settle)
+}
+
+// inflightOf counts a job as in-flight on the scheduler and returns the generation it was
+// counted in.
+func inflightOf(s *timeline.Scheduler) *generation {
+	for {
+		v, ok := pending.Load(s)
+		if !ok {
+			jobs := new(inflight)
+			jobs.current.Store(newGeneration(nil))
+			v, _ = pending.LoadOrStore(s, jobs)
+		}
+
+		// Retry if the counter is being removed, see release
+		jobs := v.(*inflight)
+		g := jobs.current.Load()
+		g.count.Add(1)
+		if !jobs.removed.Load() {
+			return g
+		}
+		g.count.Add(-1)
+	}
+}
+
+// rotate starts a new generation of jobs and returns it, so that the jobs counted in the
+// generations before it can be waited for.
+func (f *inflight) rotate() *generation {
+	for {
+		prev := f.current.Load()
+		next := newGeneration(prev)
+		if f.current.CompareAndSwap(prev, next) {
+			return next
+		}
+	}
+}
+
+// release removes the counter of the scheduler if nothing is in flight, so that the scheduler
+// is not retained once it is no longer used.
+func (f *inflight) release(s *timeline.Scheduler) {
+	if !f.removed.CompareAndSwap(false, true) {
+		return // already being removed
+	}
+
+	if g := f.current.Load(); g.count.Load() == 0 && g.prev.Load() == nil {
+		pending.CompareAndDelete(s, f)
+		return
+	}
+
+	f.removed.Store(false)
+}
@@ -0,0 +1,148 @@
+package emit
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kelindar/event"
+	"github.com/kelindar/timeline"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlush(t *testing.T) {
+	var count atomic.Int32
+	defer On(func(ev Job, now time.Time, elapsed time.Duration) error {
+		time.Sleep(5 * time.Millisecond)
+		count.Add(1)
+		return nil
+	})()
+
+	for i := 0; i < 5; i++ {
+		Next(Job{ID: i})
+	}
+	After(Job{ID: 5}, 50*time.Millisecond)
+	NextBatch([]Job{{ID: 6}, {ID: 7}})
+
+	assert.NoError(t, Flush(context.Background()))
+	assert.Equal(t, int32(8), count.Load())
+}
+
+func TestFlushTimeout(t *testing.T) {
+	defer On(func(ev Job, now time.Time, elapsed time.Duration) error {
+		return nil
+	})()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	After(Job{}, 200*time.Millisecond)
+	assert.ErrorIs(t, Flush(ctx), context.DeadlineExceeded)
+	assert.NoError(t, Flush(context.Background()))
+}
+
+func TestFlushCancelled(t *testing.T) {
+	defer On(func(ev Job, now time.Time, elapsed time.Duration) error {
+		return nil
+	})()
+
+	// The event removed from the scheduler is not waited for
+	at := Scheduler.Now().Add(time.Hour)
+	At(Job{}, at)
+	Scheduler.CancelAfter(at.Add(-time.Minute))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, Flush(ctx))
+}
+
+func TestFlushRejected(t *testing.T) {
+	previous := Scheduler
+	Scheduler = timeline.New(timeline.WithMaxJobs(1))
+	defer func() { Scheduler = previous }()
+
+	// The event rejected by the scheduler is not waited for
+	Scheduler.RunAfter(func(time.Time, time.Duration) bool { return false }, time.Hour)
+	Next(Job{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, Flush(ctx))
+}
+
+func TestFlushProducer(t *testing.T) {
+	defer On(func(ev Beat, now time.Time, elapsed time.Duration) error {
+		return nil
+	})()
+
+	// The events written after the call are not waited for
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				After(Beat{}, 30*time.Millisecond)
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	time.Sleep(10 * time.Millisecond)
+	assert.NoError(t, Flush(ctx))
+}
+
+func TestFlushOrderedTypes(t *testing.T) {
+	var ordered, typed atomic.Int32
+	defer OnOrdered(func(ev Job, c *Context) error {
+		time.Sleep(time.Millisecond)
+		ordered.Add(1)
+		return nil
+	})()
+	defer OnTypes([]uint32{Job{}.Type()}, func(ev event.Event, now time.Time, elapsed time.Duration) error {
+		time.Sleep(time.Millisecond)
+		typed.Add(1)
+		return nil
+	})()
+
+	for i := 0; i < 10; i++ {
+		Next(Job{ID: i})
+	}
+
+	assert.NoError(t, Flush(context.Background()))
+	assert.Equal(t, int32(10), ordered.Load())
+	assert.Equal(t, int32(10), typed.Load())
+}
+
+type Beat struct{}
+
+func (Beat) Type() uint32 { return 0x113 }
+
+type Job struct {
+	ID int
+}
+
+func (Job) Type() uint32 { return 0x10c }
+
+func TestDrain(t *testing.T) {
+	var count atomic.Int32
+	defer On(func(ev Job, now time.Time, elapsed time.Duration) error {
+		count.Add(1)
+		return nil
+	})()
+
+	// The events scheduled for later are not waited for
+	start := time.Now()
+	After(Job{}, 200*time.Millisecond)
+	assert.NoError(t, Drain(context.Background()))
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+	assert.Equal(t, int32(0), count.Load())
+
+	assert.NoError(t, Flush(context.Background()))
+	assert.Equal(t, int32(1), count.Load())
+}
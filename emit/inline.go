@@ -0,0 +1,69 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package emit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kelindar/event"
+)
+
+// inline contains the handlers registered for each event type, for synchronous delivery
+var inline sync.Map // map[uint32]*listeners[T]
+
+// listeners represents the handlers registered for a specific event type
+type listeners[T event.Event] struct {
+	mu       sync.Mutex
+	handlers []*func(T, time.Time, time.Duration) error // copy-on-write list of handlers
+}
+
+// NextInline delivers an event immediately to the handlers registered with On or OnType,
+// on the calling goroutine, bypassing both the scheduler and the dispatcher. The handlers are
+// invoked in the order of their registration, with the current time and no elapsed time. Since
+// the handlers may be invoked concurrently with the dispatched events, the subscribers which
+// are not safe for concurrent use should be registered with the Serial option.
+func NextInline[T event.Event](ev T) {
+	v, ok := inline.Load(ev.Type())
+	if !ok {
+		return
+	}
+
+	group := v.(*listeners[T])
+	group.mu.Lock()
+	handlers := group.handlers
+	group.mu.Unlock()
+
+	now := time.Now()
+	for _, handler := range handlers {
+		if err := (*handler)(ev, now, 0); err != nil {
+			Error(err, ev)
+		}
+	}
+}
+
+// listen registers the handler for synchronous delivery and returns the handler which
+// should be subscribed to the dispatcher, along with a function to unregister it.
+func listen[T event.Event](eventType uint32, handler func(T, time.Time, time.Duration) error) (func(T, time.Time, time.Duration) error, func()) {
+	v, _ := inline.LoadOrStore(eventType, new(listeners[T]))
+	group := v.(*listeners[T])
+
+	// Register the handler, copying the list so it can be read without locking
+	fn := &handler
+	group.mu.Lock()
+	group.handlers = append(group.handlers[:len(group.handlers):len(group.handlers)], fn)
+	group.mu.Unlock()
+
+	return handler, func() {
+		group.mu.Lock()
+		defer group.mu.Unlock()
+		handlers := make([]*func(T, time.Time, time.Duration) error, 0, len(group.handlers))
+		for _, h := range group.handlers {
+			if h != fn {
+				handlers = append(handlers, h)
+			}
+		}
+		group.handlers = handlers
+	}
+}
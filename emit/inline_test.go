@@ -0,0 +1,52 @@
+package emit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextInline(t *testing.T) {
+	var log []string
+	errors := make(chan error, 1)
+	defer OnError(func(err error, about any) {
+		errors <- err
+	})()
+
+	cancel1 := On(func(ev Step, now time.Time, elapsed time.Duration) error {
+		log = append(log, fmt.Sprintf("first %d", ev.N))
+		return nil
+	})
+	defer On(func(ev Step, now time.Time, elapsed time.Duration) error {
+		log = append(log, fmt.Sprintf("second %d", ev.N))
+		assert.Zero(t, elapsed)
+		return fmt.Errorf("failed %d", ev.N)
+	})()
+
+	// Delivered before returning, in order of registration
+	NextInline(Step{N: 1})
+	assert.Equal(t, []string{"first 1", "second 1"}, log)
+	assert.EqualError(t, <-errors, "failed 1")
+
+	// Cancelled handlers are no longer invoked
+	cancel1()
+	NextInline(Step{N: 2})
+	assert.Equal(t, []string{"first 1", "second 1", "second 2"}, log)
+	assert.EqualError(t, <-errors, "failed 2")
+}
+
+func TestNextInlineNoHandlers(t *testing.T) {
+	assert.NotPanics(t, func() {
+		NextInline(Dynamic{ID: 46})
+	})
+}
+
+// ------------------------------------- Test Events -------------------------------------
+
+type Step struct {
+	N int
+}
+
+func (Step) Type() uint32 { return 0x107 }
@@ -0,0 +1,73 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package emit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kelindar/event"
+)
+
+// keyed contains the subscriptions registered with an explicit key
+var keyed struct {
+	sync.Mutex
+	subs map[keyedID]*context.CancelFunc
+}
+
+// keyedID represents the identity of a keyed subscription
+type keyedID struct {
+	typ uint32 // The event type of the subscription
+	key any    // The key provided by the subscriber
+}
+
+// OnKeyed subscribes to an event, similarly to On, but registers the subscription under a
+// 'key' so that it can be cancelled later with Off, without keeping the cancel function around.
+// The key must be comparable and there is at most one subscription per key and event type, so
+// subscribing again with the same key replaces the previous subscription.
+func OnKeyed[T event.Event](key any, handler func(event T, now time.Time, elapsed time.Duration) error, options ...Option) context.CancelFunc {
+	var ev T
+	id := keyedID{typ: ev.Type(), key: key}
+	cancel := On(handler, options...)
+	self := &cancel
+
+	keyed.Lock()
+	if keyed.subs == nil {
+		keyed.subs = make(map[keyedID]*context.CancelFunc)
+	}
+	prev := keyed.subs[id]
+	keyed.subs[id] = self
+	keyed.Unlock()
+
+	if prev != nil {
+		(*prev)()
+	}
+
+	return func() {
+		keyed.Lock()
+		if keyed.subs[id] == self {
+			delete(keyed.subs, id)
+		}
+		keyed.Unlock()
+		cancel()
+	}
+}
+
+// Off cancels the subscription to the event of type T which was registered with OnKeyed
+// under the 'key'. It returns whether such a subscription was found.
+func Off[T event.Event](key any) bool {
+	var ev T
+	id := keyedID{typ: ev.Type(), key: key}
+
+	keyed.Lock()
+	cancel, ok := keyed.subs[id]
+	delete(keyed.subs, id)
+	keyed.Unlock()
+
+	if ok {
+		(*cancel)()
+	}
+	return ok
+}
@@ -0,0 +1,53 @@
+package emit
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnKeyed(t *testing.T) {
+	var first, second atomic.Int32
+	OnKeyed("owner", func(ev Owned, now time.Time, elapsed time.Duration) error {
+		first.Add(1)
+		return nil
+	})
+
+	Next(Owned{})
+	assert.NoError(t, Flush(context.Background()))
+	assert.Equal(t, int32(1), first.Load())
+
+	// Subscribing with the same key replaces the subscription
+	OnKeyed("owner", func(ev Owned, now time.Time, elapsed time.Duration) error {
+		second.Add(1)
+		return nil
+	})
+
+	Next(Owned{})
+	assert.NoError(t, Flush(context.Background()))
+	assert.Equal(t, int32(1), first.Load())
+	assert.Equal(t, int32(1), second.Load())
+
+	// Unsubscribe by the key
+	assert.True(t, Off[Owned]("owner"))
+	assert.False(t, Off[Owned]("owner"))
+	Next(Owned{})
+	assert.NoError(t, Flush(context.Background()))
+	assert.Equal(t, int32(1), second.Load())
+}
+
+func TestOnKeyedCancel(t *testing.T) {
+	cancel := OnKeyed(1, func(ev Owned, now time.Time, elapsed time.Duration) error {
+		return nil
+	})
+
+	cancel()
+	assert.False(t, Off[Owned](1))
+}
+
+type Owned struct{}
+
+func (Owned) Type() uint32 { return 0x10e }
@@ -0,0 +1,45 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package emit
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/kelindar/event"
+)
+
+// logger contains the optional structured logger of the events and errors
+var logger atomic.Pointer[slog.Logger]
+
+// WithLogger logs every written event at debug level, along with its type, time and
+// elapsed time, and every error at error level using the structured logger. Passing
+// nil disables the logging, in which case writing an event is not affected.
+func WithLogger(l *slog.Logger) {
+	logger.Store(l)
+}
+
+// logEvent logs the event, if a logger is installed
+func logEvent(eventType uint32, ev event.Event, now time.Time, elapsed time.Duration) {
+	if l := logger.Load(); l != nil {
+		l.LogAttrs(context.Background(), slog.LevelDebug, "emit: event",
+			slog.Uint64("type", uint64(eventType)),
+			slog.Time("time", now),
+			slog.Duration("elapsed", elapsed),
+			slog.Any("event", ev),
+		)
+	}
+}
+
+// logError logs the error, if a logger is installed
+func logError(err error, about any) {
+	if l := logger.Load(); l != nil {
+		l.LogAttrs(context.Background(), slog.LevelError, "emit: error",
+			slog.String("error", err.Error()),
+			slog.Any("about", about),
+		)
+	}
+}
@@ -0,0 +1,59 @@
+package emit
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithLogger(t *testing.T) {
+	var out buffer
+	WithLogger(slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	})))
+
+	events := make(chan Step, 1)
+	defer On(func(ev Step, now time.Time, elapsed time.Duration) error {
+		events <- ev
+		return nil
+	})()
+
+	Next(Step{N: 1})
+	<-events
+	Error(errors.New("boom"), nil)
+	WithLogger(nil)
+
+	// Nothing is logged once disabled
+	Next(Step{N: 2})
+	<-events
+	Error(errors.New("ignored"), nil)
+
+	log := out.String()
+	assert.Contains(t, log, `level=DEBUG msg="emit: event" type=263`)
+	assert.Contains(t, log, `level=ERROR msg="emit: error" error=boom`)
+	assert.NotContains(t, log, "N:2")
+	assert.NotContains(t, log, "ignored")
+}
+
+// buffer represents a concurrency-safe buffer
+type buffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *buffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *buffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,89 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package emit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kelindar/event"
+)
+
+// Handler represents a type-erased event handler, as seen by a middleware.
+type Handler = func(ev event.Event, now time.Time, elapsed time.Duration) error
+
+// Middleware wraps a handler with a cross-cutting behavior such as timing, logging
+// or recovery. It can call 'next' to continue the chain, or short-circuit it.
+type Middleware = func(next Handler) Handler
+
+// middleware contains the currently installed middleware
+var middleware struct {
+	sync.RWMutex
+	chain []*installed
+}
+
+// installed represents a middleware installed with Use, until it is removed
+type installed struct {
+	wrap    Middleware
+	removed atomic.Bool
+}
+
+// Use installs a middleware which is invoked around every handler registered
+// afterwards. The first installed middleware is the outermost one. It returns a
+// function which removes the middleware, after which it is no longer invoked by
+// any handler, including the ones registered while it was installed.
+func Use(mw Middleware) context.CancelFunc {
+	entry := &installed{wrap: mw}
+	middleware.Lock()
+	defer middleware.Unlock()
+	middleware.chain = append(middleware.chain, entry)
+
+	return func() {
+		entry.removed.Store(true)
+		middleware.Lock()
+		defer middleware.Unlock()
+		chain := make([]*installed, 0, len(middleware.chain))
+		for _, v := range middleware.chain {
+			if v != entry {
+				chain = append(chain, v)
+			}
+		}
+		middleware.chain = chain
+	}
+}
+
+// handlerOf wraps the typed handler with the currently installed middleware. If
+// there's no middleware installed, the handler is returned as-is.
+func handlerOf[T event.Event](handler func(T, time.Time, time.Duration) error) func(T, time.Time, time.Duration) error {
+	middleware.RLock()
+	defer middleware.RUnlock()
+	if len(middleware.chain) == 0 {
+		return handler
+	}
+
+	// Build the chain, starting with the innermost handler
+	next := func(ev event.Event, now time.Time, elapsed time.Duration) error {
+		return handler(ev.(T), now, elapsed)
+	}
+	for i := len(middleware.chain) - 1; i >= 0; i-- {
+		next = middleware.chain[i].around(next)
+	}
+
+	return func(ev T, now time.Time, elapsed time.Duration) error {
+		return next(ev, now, elapsed)
+	}
+}
+
+// around wraps the handler with the middleware, which is skipped once it is removed
+func (m *installed) around(next Handler) Handler {
+	wrapped := m.wrap(next)
+	return func(ev event.Event, now time.Time, elapsed time.Duration) error {
+		if m.removed.Load() {
+			return next(ev, now, elapsed)
+		}
+		return wrapped(ev, now, elapsed)
+	}
+}
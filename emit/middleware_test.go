@@ -0,0 +1,65 @@
+package emit
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kelindar/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUse(t *testing.T) {
+	var seen atomic.Int32
+	remove := Use(func(next Handler) Handler {
+		return func(ev event.Event, now time.Time, elapsed time.Duration) error {
+			msg, ok := ev.(Annotated)
+			if !ok {
+				return next(ev, now, elapsed)
+			}
+
+			seen.Add(1)
+			if msg.Skip {
+				return fmt.Errorf("skipped")
+			}
+			return next(ev, now, elapsed)
+		}
+	})
+	defer remove()
+
+	errors := make(chan error)
+	defer OnError(func(err error, about any) {
+		errors <- err
+	})()
+
+	events := make(chan Annotated)
+	defer On(func(ev Annotated, now time.Time, elapsed time.Duration) error {
+		events <- ev
+		return nil
+	})()
+
+	// Pass through the middleware
+	Next(Annotated{Text: "Hello"})
+	assert.Equal(t, "Hello", (<-events).Text)
+
+	// Short-circuit in the middleware
+	Next(Annotated{Skip: true})
+	assert.Equal(t, "skipped", (<-errors).Error())
+	assert.Equal(t, int32(2), seen.Load())
+
+	// Once removed, the middleware is no longer invoked
+	remove()
+	Next(Annotated{Skip: true})
+	assert.True(t, (<-events).Skip)
+	assert.Equal(t, int32(2), seen.Load())
+}
+
+// ------------------------------------- Test Events -------------------------------------
+
+type Annotated struct {
+	Text string
+	Skip bool
+}
+
+func (Annotated) Type() uint32 { return 0x101 }
@@ -0,0 +1,109 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package emit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/kelindar/event"
+)
+
+const (
+	namespaceBase = 1 << 31 // Namespaced event types are within [1<<31, math.MaxUint32)
+	namespaceMask = 0x7fff  // Maximum event type which can be namespaced
+)
+
+// Emitter represents an emitter whose events are partitioned by a namespace, so that two
+// modules can both use the same event type without cross-delivery. The namespace and the
+// event type are combined into the upper half of the 32-bit type space, which is reserved
+// for namespaces and does not collide with the timers or the error events.
+type Emitter struct {
+	ns uint16
+}
+
+// Namespace returns an emitter for the specified namespace.
+func Namespace(id uint16) Emitter {
+	return Emitter{ns: id}
+}
+
+// typeOf combines the namespace and the event type
+func (e Emitter) typeOf(eventType uint32) uint32 {
+	typ := namespaceBase | uint32(e.ns)<<15 | eventType
+	if eventType > namespaceMask || typ == math.MaxUint32 {
+		panic(fmt.Errorf("emit: event type 0x%x can not be namespaced", eventType))
+	}
+	return typ
+}
+
+// scoped represents a forwarded event within a namespace
+type scoped struct {
+	signal[event.Event]
+	typ uint32
+}
+
+// Type returns the namespaced type of the event
+func (e scoped) Type() uint32 {
+	return e.typ
+}
+
+// On subscribes to an event of the specified type within the namespace.
+func (e Emitter) On(eventType uint32, handler func(ev event.Event, now time.Time, elapsed time.Duration) error) context.CancelFunc {
+	typ := e.typeOf(eventType)
+	handler = recovered(handlerOf(handler))
+	untrack := track(typ)
+	cancel := event.SubscribeTo(event.Default, typ, func(m scoped) {
+		if err := handler(m.Data, m.Time, m.Elapsed); err != nil {
+			Error(err, m.Data)
+		}
+	})
+
+	return func() {
+		untrack()
+		cancel()
+	}
+}
+
+// Next writes an event within the namespace during the next tick.
+func (e Emitter) Next(ev event.Event) {
+	Scheduler.Run(e.emit(ev))
+}
+
+// At writes an event within the namespace at specific 'at' time.
+func (e Emitter) At(ev event.Event, at time.Time) {
+	Scheduler.RunAt(e.emit(ev), at)
+}
+
+// After writes an event within the namespace after a 'delay'.
+func (e Emitter) After(ev event.Event, after time.Duration) {
+	Scheduler.RunAfter(e.emit(ev), after)
+}
+
+// Every writes an event within the namespace at 'interval' intervals, starting at the next boundary tick.
+func (e Emitter) Every(ev event.Event, interval time.Duration) {
+	Scheduler.RunEvery(e.emit(ev), interval)
+}
+
+// emit writes a namespaced event into the dispatcher
+func (e Emitter) emit(ev event.Event) func(now time.Time, elapsed time.Duration) bool {
+	typ := e.typeOf(ev.Type())
+	return func(now time.Time, elapsed time.Duration) bool {
+		logEvent(typ, ev, now, elapsed)
+		if !handled(typ, ev) {
+			return true
+		}
+
+		event.Publish(event.Default, scoped{
+			typ: typ,
+			signal: signal[event.Event]{
+				Data:    ev,
+				Time:    now,
+				Elapsed: elapsed,
+			},
+		})
+		return true
+	}
+}
@@ -0,0 +1,68 @@
+package emit
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/kelindar/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespace(t *testing.T) {
+	a, b := Namespace(1), Namespace(2)
+	events := make(chan string, 8)
+	defer a.On(TypeEvent2, func(ev event.Event, now time.Time, elapsed time.Duration) error {
+		events <- "a:" + ev.(MyEvent2).Text
+		return nil
+	})()
+	defer b.On(TypeEvent2, func(ev event.Event, now time.Time, elapsed time.Duration) error {
+		events <- "b:" + ev.(MyEvent2).Text
+		return nil
+	})()
+
+	a.Next(MyEvent2{Text: "1"})
+	assert.Equal(t, "a:1", <-events)
+
+	b.After(MyEvent2{Text: "2"}, 10*time.Millisecond)
+	assert.Equal(t, "b:2", <-events)
+
+	a.At(MyEvent2{Text: "3"}, time.Now())
+	assert.Equal(t, "a:3", <-events)
+}
+
+func TestNamespaceTypes(t *testing.T) {
+	assert.Equal(t, uint32(1<<31|1), Namespace(0).typeOf(1))
+	assert.Equal(t, uint32(1<<31|1<<15|1), Namespace(1).typeOf(1))
+	assert.NotEqual(t, uint32(math.MaxUint32), Namespace(math.MaxUint16).typeOf(0x7ffe))
+	assert.Panics(t, func() {
+		Namespace(math.MaxUint16).typeOf(0x7fff)
+	})
+	assert.Panics(t, func() {
+		Namespace(1).typeOf(1 << 15)
+	})
+}
+
+func TestNamespacePanic(t *testing.T) {
+	errors := make(chan any, 1)
+	defer OnError(func(err error, about any) {
+		errors <- about
+	})()
+
+	// A panic is reported and the handler keeps receiving events
+	events := make(chan string, 1)
+	defer Namespace(3).On(TypeEvent2, func(ev event.Event, now time.Time, elapsed time.Duration) error {
+		if text := ev.(MyEvent2).Text; text != "boom" {
+			events <- text
+			return nil
+		}
+
+		panic("boom")
+	})()
+
+	Namespace(3).Next(MyEvent2{Text: "boom"})
+	assert.Equal(t, MyEvent2{Text: "boom"}, <-errors)
+
+	Namespace(3).Next(MyEvent2{Text: "1"})
+	assert.Equal(t, "1", <-events)
+}
@@ -0,0 +1,97 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package emit
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/kelindar/event"
+)
+
+// Option represents a subscription option.
+type Option func(*config)
+
+// config represents the configuration of a subscription.
+type config struct {
+	sinceLast bool          // Recompute elapsed since the last receipt of this subscriber
+	serial    bool          // Never invoke the handler concurrently
+	maxAge    time.Duration // Maximum delay between the emission and the delivery, 0 if none
+}
+
+// ErrStale is reported through OnError when an event is not delivered to a subscriber
+// because it is older than the maximum age set with MaxAge.
+var ErrStale = errors.New("emit: event is too old to be delivered")
+
+// SinceLast is a subscription option which makes the subscriber receive the elapsed time
+// since it last received this event type, rather than the elapsed time of the scheduled
+// task. The elapsed time is zero on the first receipt.
+var SinceLast Option = func(c *config) {
+	c.sinceLast = true
+}
+
+// Serial is a subscription option which guarantees that the handler of the subscriber is
+// never invoked concurrently. The events dispatched to a subscriber are already delivered one
+// at a time, but synchronous deliveries such as NextInline may happen at the same time, so
+// the handler is serialized across all of them. This is implied by SinceLast.
+var Serial Option = func(c *config) {
+	c.serial = true
+}
+
+// MaxAge is a subscription option which skips the events that are delivered more than 'age'
+// after the time they were scheduled for, for example after a stall of the scheduler. The
+// lateness is measured on the clock of the scheduler, regardless of the time the handler
+// receives. Instead of invoking the handler, ErrStale is reported through OnError along with
+// the skipped event. The events delivered with NextInline are never stale.
+func MaxAge(age time.Duration) Option {
+	return func(c *config) {
+		c.maxAge = age
+	}
+}
+
+// configOf returns the configuration of a subscription with the options applied
+func configOf(options []Option) (c config) {
+	for _, opt := range options {
+		opt(&c)
+	}
+	return
+}
+
+// configure wraps the handler according to the subscription options. The maximum age of the
+// events is checked by the subscriber itself, since it depends on when they were written.
+func configure[T event.Event](handler func(T, time.Time, time.Duration) error, options []Option) func(T, time.Time, time.Duration) error {
+	c := configOf(options)
+	if c.sinceLast {
+		handler = sinceLast(handler)
+	}
+	if c.serial || c.sinceLast {
+		handler = serial(handler)
+	}
+	return handler
+}
+
+// serial serializes the invocations of the handler
+func serial[T event.Event](handler func(T, time.Time, time.Duration) error) func(T, time.Time, time.Duration) error {
+	var mu sync.Mutex
+	return func(ev T, now time.Time, elapsed time.Duration) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return handler(ev, now, elapsed)
+	}
+}
+
+// sinceLast tracks the last receipt time of the subscriber and recomputes elapsed
+func sinceLast[T event.Event](handler func(T, time.Time, time.Duration) error) func(T, time.Time, time.Duration) error {
+	var last time.Time
+	return func(ev T, now time.Time, _ time.Duration) error {
+		elapsed := time.Duration(0)
+		if !last.IsZero() {
+			elapsed = now.Sub(last)
+		}
+
+		last = now
+		return handler(ev, now, elapsed)
+	}
+}
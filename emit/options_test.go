@@ -0,0 +1,101 @@
+package emit
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSinceLast(t *testing.T) {
+	elapsed := make(chan time.Duration, 8)
+	defer On(func(ev Sampled, now time.Time, dt time.Duration) error {
+		elapsed <- dt
+		return nil
+	}, SinceLast)()
+
+	After(Sampled{}, 50*time.Millisecond)
+	After(Sampled{}, 100*time.Millisecond)
+
+	assert.Equal(t, time.Duration(0), <-elapsed)
+	assert.InDelta(t, 50*time.Millisecond, <-elapsed, float64(20*time.Millisecond))
+}
+
+func TestSinceLastConfigure(t *testing.T) {
+	var got []time.Duration
+	handler := configure(func(ev Sampled, now time.Time, dt time.Duration) error {
+		got = append(got, dt)
+		return nil
+	}, []Option{SinceLast})
+
+	now := time.Unix(0, 0)
+	handler(Sampled{}, now, time.Hour)
+	handler(Sampled{}, now.Add(30*time.Millisecond), time.Hour)
+	handler(Sampled{}, now.Add(100*time.Millisecond), time.Hour)
+	assert.Equal(t, []time.Duration{0, 30 * time.Millisecond, 70 * time.Millisecond}, got)
+}
+
+func TestSerial(t *testing.T) {
+	var running, overlaps atomic.Int32
+	var wg sync.WaitGroup
+	wg.Add(200)
+	defer On(func(ev Sampled, now time.Time, dt time.Duration) error {
+		defer wg.Done()
+		if running.Add(1) > 1 {
+			overlaps.Add(1)
+		}
+
+		time.Sleep(10 * time.Microsecond)
+		running.Add(-1)
+		return nil
+	}, Serial)()
+
+	// Deliver both through the dispatcher and synchronously, at the same time
+	go func() {
+		for i := 0; i < 100; i++ {
+			Next(Sampled{ID: i})
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		NextInline(Sampled{ID: i})
+	}
+
+	wg.Wait()
+	assert.Zero(t, overlaps.Load())
+}
+
+func TestMaxAge(t *testing.T) {
+	errs := make(chan error, 1)
+	defer OnError(func(err error, about any) {
+		errs <- err
+	})()
+
+	events := make(chan int, 1)
+	defer On(func(ev Sampled, now time.Time, dt time.Duration) error {
+		events <- ev.ID
+		return nil
+	}, MaxAge(time.Second))()
+
+	// The time given by the caller is not the time of the scheduler
+	NextWithTime(Sampled{ID: 1}, time.Now().Add(-time.Hour))
+	assert.Equal(t, 1, <-events)
+
+	// The event delivered an hour after the time it was scheduled for is stale
+	stale := signal[Sampled]{Data: Sampled{ID: 2}, At: Scheduler.Now().Add(-time.Hour)}
+	assert.True(t, stale.stale(time.Second))
+	publish(stale)
+	assert.ErrorIs(t, <-errs, ErrStale)
+
+	Next(Sampled{ID: 3})
+	assert.Equal(t, 3, <-events)
+}
+
+// ------------------------------------- Test Events -------------------------------------
+
+type Sampled struct {
+	ID int
+}
+
+func (Sampled) Type() uint32 { return 0x102 }
@@ -0,0 +1,141 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package emit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kelindar/event"
+)
+
+// Context represents the context of an event delivered to an ordered chain of handlers.
+type Context struct {
+	Now     time.Time     // The time at which the event was emitted
+	Elapsed time.Duration // The time elapsed since the last event
+	stop    bool
+}
+
+// Stop prevents the remaining handlers of the chain from receiving the event.
+func (c *Context) Stop() {
+	c.stop = true
+}
+
+// ordered contains the ordered chains of handlers, per event type
+var ordered sync.Map // map[uint32]*chain[T]
+
+// chain represents an ordered chain of handlers for a specific event type
+type chain[T event.Event] struct {
+	mu       sync.Mutex
+	handlers []*func(T, *Context) error // copy-on-write list of handlers
+	ctx      Context                    // the context of the event being dispatched
+	self     *flusher                   // the flusher of the chain, see Flush
+	cancel   context.CancelFunc         // unsubscribes the chain, nil if not subscribed
+	closed   bool                       // whether the chain was removed with its last handler
+}
+
+// OnOrdered subscribes to an event, similarly to On, but the handlers registered through
+// OnOrdered for the same type are invoked serially, in the order of their registration. A
+// handler can call Stop() on the context to prevent the remaining handlers from being invoked.
+// When a handler stops the chain, the event is considered handled and the error it may have
+// returned is not reported.
+func OnOrdered[T event.Event](handler func(ev T, c *Context) error) context.CancelFunc {
+	var ev T
+	untrack := track(ev.Type())
+
+	// Run the handler through the middleware, the context being the one of the chain since
+	// the events are dispatched serially
+	var group *chain[T]
+	wrapped := recovered(handlerOf(func(ev T, _ time.Time, _ time.Duration) error {
+		return handler(ev, &group.ctx)
+	}))
+
+	// Register the handler, copying the list so it can be read without locking
+	fn := new(func(T, *Context) error)
+	*fn = func(ev T, c *Context) error {
+		return wrapped(ev, c.Now, c.Elapsed)
+	}
+	group = join(ev.Type(), fn)
+
+	return func() {
+		untrack()
+		group.remove(ev.Type(), fn)
+	}
+}
+
+// join adds the handler to the chain of the event type, subscribing the chain when its
+// first handler is added.
+func join[T event.Event](eventType uint32, fn *func(T, *Context) error) *chain[T] {
+	for {
+		v, _ := ordered.LoadOrStore(eventType, &chain[T]{})
+		group := v.(*chain[T])
+
+		group.mu.Lock()
+		if group.closed {
+			group.mu.Unlock()
+			continue // The chain is being removed, retry with a fresh one
+		}
+
+		if group.cancel == nil {
+			group.self = flushable[T](event.Default, eventType)
+			cancel := event.Subscribe(event.Default, group.dispatch)
+			unflush := group.self.enable()
+			group.cancel = func() {
+				unflush()
+				cancel()
+			}
+		}
+
+		group.handlers = append(group.handlers[:len(group.handlers):len(group.handlers)], fn)
+		group.mu.Unlock()
+		return group
+	}
+}
+
+// remove removes the handler from the chain, unsubscribing the chain with its last handler
+func (c *chain[T]) remove(eventType uint32, fn *func(T, *Context) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	handlers := make([]*func(T, *Context) error, 0, len(c.handlers))
+	for _, h := range c.handlers {
+		if h != fn {
+			handlers = append(handlers, h)
+		}
+	}
+
+	c.handlers = handlers
+	if len(handlers) > 0 || c.closed {
+		return
+	}
+
+	c.closed = true
+	ordered.CompareAndDelete(eventType, c)
+	c.cancel()
+}
+
+// dispatch delivers the event to the chain of handlers
+func (c *chain[T]) dispatch(m signal[T]) {
+	if m.barrier != nil {
+		if m.barrier.owner == c.self {
+			close(m.barrier.done)
+		}
+		return
+	}
+
+	c.mu.Lock()
+	handlers := c.handlers
+	c.mu.Unlock()
+
+	c.ctx = Context{Now: m.Time, Elapsed: m.Elapsed}
+	for _, handler := range handlers {
+		err := (*handler)(m.Data, &c.ctx)
+		switch {
+		case c.ctx.stop:
+			return
+		case err != nil:
+			Error(err, m.Data)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package emit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kelindar/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnOrdered(t *testing.T) {
+	log := make(chan string, 8)
+	defer OnOrdered(func(ev Command, c *Context) error {
+		log <- "first " + ev.Name
+		if ev.Name == "handled" {
+			c.Stop()
+			return fmt.Errorf("not reported")
+		}
+		return nil
+	})()
+
+	cancel := OnOrdered(func(ev Command, c *Context) error {
+		log <- "second " + ev.Name
+		return nil
+	})
+
+	errors := make(chan error, 8)
+	defer OnError(func(err error, about any) {
+		if _, ok := about.(Command); ok {
+			errors <- err
+		}
+	})()
+
+	Next(Command{Name: "pass"})
+	assert.Equal(t, "first pass", <-log)
+	assert.Equal(t, "second pass", <-log)
+
+	Next(Command{Name: "handled"})
+	assert.Equal(t, "first handled", <-log)
+
+	// Unsubscribe the second handler
+	cancel()
+	Next(Command{Name: "again"})
+	assert.Equal(t, "first again", <-log)
+	assert.Empty(t, log)
+	assert.Empty(t, errors)
+}
+
+func TestOnOrderedError(t *testing.T) {
+	errors := make(chan error, 1)
+	defer OnError(func(err error, about any) {
+		if _, ok := about.(Query); ok {
+			errors <- err
+		}
+	})()
+
+	defer OnOrdered(func(ev Query, c *Context) error {
+		return fmt.Errorf("failed")
+	})()
+
+	Next(Query{})
+	assert.Equal(t, "failed", (<-errors).Error())
+}
+
+func TestOnOrderedPanic(t *testing.T) {
+	errors := make(chan error, 1)
+	defer OnError(func(err error, about any) {
+		if _, ok := about.(Audit); ok {
+			errors <- err
+		}
+	})()
+
+	// The panic is reported and the next handler of the chain still receives the event
+	log := make(chan string, 1)
+	defer OnOrdered(func(ev Audit, c *Context) error {
+		panic("boom")
+	})()
+	defer OnOrdered(func(ev Audit, c *Context) error {
+		log <- ev.Name
+		return nil
+	})()
+
+	Next(Audit{Name: "first"})
+	assert.Equal(t, "first", <-log)
+	assert.Equal(t, "emit: handler of event type 0x111 panicked: boom", (<-errors).Error())
+
+	Next(Audit{Name: "second"})
+	assert.Equal(t, "second", <-log)
+}
+
+func TestOnOrderedUnsubscribe(t *testing.T) {
+	cancel := OnOrdered(func(ev Ledger, c *Context) error {
+		return nil
+	})
+
+	// The chain is removed along with its last handler
+	_, ok := ordered.Load(Ledger{}.Type())
+	assert.True(t, ok)
+	cancel()
+	_, ok = ordered.Load(Ledger{}.Type())
+	assert.False(t, ok)
+
+	// A new chain is subscribed for the next handler
+	log := make(chan string, 1)
+	defer OnOrdered(func(ev Ledger, c *Context) error {
+		log <- ev.Name
+		return nil
+	})()
+
+	Next(Ledger{Name: "again"})
+	assert.Equal(t, "again", <-log)
+	assert.NoError(t, Flush(context.Background()))
+}
+
+func TestOnOrderedMiddleware(t *testing.T) {
+	defer Use(func(next Handler) Handler {
+		return func(ev event.Event, now time.Time, elapsed time.Duration) error {
+			if msg, ok := ev.(Ledger); ok && msg.Name == "denied" {
+				return fmt.Errorf("denied")
+			}
+			return next(ev, now, elapsed)
+		}
+	})()
+
+	errors := make(chan error, 1)
+	defer OnError(func(err error, about any) {
+		if _, ok := about.(Ledger); ok {
+			errors <- err
+		}
+	})()
+
+	log := make(chan string, 1)
+	defer OnOrdered(func(ev Ledger, c *Context) error {
+		log <- ev.Name
+		return nil
+	})()
+
+	Next(Ledger{Name: "denied"})
+	assert.Equal(t, "denied", (<-errors).Error())
+
+	Next(Ledger{Name: "allowed"})
+	assert.Equal(t, "allowed", <-log)
+	assert.Empty(t, log)
+}
+
+// ------------------------------------- Test Events -------------------------------------
+
+type Command struct {
+	Name string
+}
+
+func (Command) Type() uint32 { return 0x103 }
+
+type Query struct{}
+
+func (Query) Type() uint32 { return 0x104 }
+
+type Audit struct {
+	Name string
+}
+
+func (Audit) Type() uint32 { return 0x111 }
+
+type Ledger struct {
+	Name string
+}
+
+func (Ledger) Type() uint32 { return 0x114 }
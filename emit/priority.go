@@ -0,0 +1,32 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package emit
+
+import (
+	"sync"
+)
+
+// priorities contains the delivery priorities of the event types, map[uint32]uint8
+var priorities sync.Map
+
+// SetPriority sets the delivery priority of an event type. The events written with Next, At
+// or After which are due on the same tick are delivered in the order of their priority, the
+// highest first. Event types have the lowest priority of 0 by default, and setting the
+// priority back to 0 removes it.
+func SetPriority(eventType uint32, priority uint8) {
+	if priority == 0 {
+		priorities.Delete(eventType)
+		return
+	}
+
+	priorities.Store(eventType, priority)
+}
+
+// priorityOf returns the delivery priority of an event type
+func priorityOf(eventType uint32) (uint8, bool) {
+	if v, ok := priorities.Load(eventType); ok {
+		return v.(uint8), true
+	}
+	return 0, false
+}
@@ -0,0 +1,46 @@
+package emit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kelindar/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetPriority(t *testing.T) {
+	SetPriority(61, 1)
+	SetPriority(63, 5)
+	defer SetPriority(61, 0)
+	defer SetPriority(63, 0)
+
+	// Unhandled events are observed synchronously, in the order they are written
+	order := make(chan uint32, 8)
+	OnUnhandled(func(ev event.Event) {
+		if typ := ev.Type(); typ >= 60 && typ <= 63 {
+			order <- typ
+		}
+	})
+	defer OnUnhandled(nil)
+
+	at := time.Now().Add(50 * time.Millisecond)
+	for id := 60; id <= 63; id++ {
+		At(Dynamic{ID: id}, at)
+	}
+
+	assert.Equal(t, uint32(63), <-order)
+	assert.Equal(t, uint32(61), <-order)
+	assert.Equal(t, uint32(60), <-order)
+	assert.Equal(t, uint32(62), <-order)
+}
+
+func TestPriorityOf(t *testing.T) {
+	SetPriority(64, 3)
+	p, ok := priorityOf(64)
+	assert.True(t, ok)
+	assert.Equal(t, uint8(3), p)
+
+	SetPriority(64, 0)
+	_, ok = priorityOf(64)
+	assert.False(t, ok)
+}
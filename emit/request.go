@@ -0,0 +1,63 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package emit
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/kelindar/event"
+)
+
+// nextRequestID is the last generated correlation identifier
+var nextRequestID atomic.Uint64
+
+// Correlated represents an event which carries a correlation identifier, so that a
+// response can be matched with the request that caused it.
+type Correlated interface {
+	event.Event
+	CorrelationID() uint64
+}
+
+// Correlator represents a request event which can be assigned a correlation identifier.
+type Correlator[T any] interface {
+	Correlated
+	WithCorrelationID(id uint64) T
+}
+
+// Request writes a request event during the next tick and waits for a response event
+// carrying the same correlation identifier. The identifier is generated and assigned to
+// the request, the responder is expected to copy it into the response. If no response
+// is received within the 'timeout', an error wrapping context.DeadlineExceeded is returned.
+func Request[Req Correlator[Req], Resp Correlated](ev Req, timeout time.Duration) (Resp, error) {
+	id := nextRequestID.Add(1)
+	reply := make(chan Resp, 1)
+	defer On(func(ev Resp, now time.Time, elapsed time.Duration) error {
+		if ev.CorrelationID() == id {
+			select {
+			case reply <- ev:
+			default: // Only the first response is kept
+			}
+		}
+		return nil
+	})()
+
+	// Expire the request on the scheduler's clock
+	expired := make(chan struct{})
+	Scheduler.RunAfter(func(now time.Time, elapsed time.Duration) bool {
+		close(expired)
+		return false
+	}, timeout)
+
+	Next(ev.WithCorrelationID(id))
+	select {
+	case resp := <-reply:
+		return resp, nil
+	case <-expired:
+		var zero Resp
+		return zero, fmt.Errorf("emit: request 0x%x timed out, %w", ev.Type(), context.DeadlineExceeded)
+	}
+}
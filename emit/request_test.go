@@ -0,0 +1,47 @@
+package emit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequest(t *testing.T) {
+	defer On(func(ev Ping, now time.Time, elapsed time.Duration) error {
+		Next(Pong{ID: ev.ID + 1000}) // uncorrelated response
+		Next(Pong{ID: ev.ID, Text: "pong " + ev.Text})
+		return nil
+	})()
+
+	resp, err := Request[Ping, Pong](Ping{Text: "hello"}, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "pong hello", resp.Text)
+	assert.NotZero(t, resp.ID)
+}
+
+func TestRequestTimeout(t *testing.T) {
+	resp, err := Request[Ping, Pong](Ping{Text: "hello"}, 30*time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, Pong{}, resp)
+}
+
+// ------------------------------------- Test Events -------------------------------------
+
+type Ping struct {
+	ID   uint64
+	Text string
+}
+
+func (Ping) Type() uint32                       { return 0x105 }
+func (e Ping) CorrelationID() uint64            { return e.ID }
+func (e Ping) WithCorrelationID(id uint64) Ping { e.ID = id; return e }
+
+type Pong struct {
+	ID   uint64
+	Text string
+}
+
+func (Pong) Type() uint32            { return 0x106 }
+func (e Pong) CorrelationID() uint64 { return e.ID }
@@ -0,0 +1,85 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package emit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kelindar/event"
+)
+
+// retained contains the sticky slots for the event types that are retained
+var retained sync.Map // map[uint32]*sticky
+
+// sticky represents the last published value of a retained event type
+type sticky struct {
+	mu    sync.Mutex
+	value any // The last published signal[T], nil if none
+}
+
+// Retain enables the retention of the last published event of type T, so that
+// subscribers registered with OnSticky receive it as soon as they subscribe.
+func Retain[T event.Event]() {
+	var ev T
+	retained.LoadOrStore(ev.Type(), new(sticky))
+}
+
+// OnSticky subscribes to an event, similarly to On, but immediately receives the
+// most recent event of type T (if any) upon subscription. The event type must be
+// retained with Retain, otherwise this behaves exactly like On.
+func OnSticky[T event.Event](handler func(event T, now time.Time, elapsed time.Duration) error) context.CancelFunc {
+	var ev T
+	v, ok := retained.Load(ev.Type())
+	if !ok {
+		return On(handler)
+	}
+
+	// Serialize the handler so the replayed event is always delivered first
+	var mu sync.Mutex
+	mu.Lock()
+	defer mu.Unlock()
+
+	// Subscribe and take a snapshot of the last value atomically with respect
+	// to publishing, so that no event is missed or delivered twice.
+	slot := v.(*sticky)
+	slot.mu.Lock()
+	cancel := On(func(ev T, now time.Time, elapsed time.Duration) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return handler(ev, now, elapsed)
+	})
+	last, ok := slot.value.(signal[T])
+	slot.mu.Unlock()
+
+	// Replay the last event, if any
+	if ok {
+		if err := handler(last.Data, last.Time, last.Elapsed); err != nil {
+			Error(err, last.Data)
+		}
+	}
+
+	return cancel
+}
+
+// publish writes the signal into the dispatcher and retains it if required
+func publish[T event.Event](m signal[T]) {
+	logEvent(m.Data.Type(), m.Data, m.Time, m.Elapsed)
+	v, ok := retained.Load(m.Data.Type())
+	if !ok {
+		if handled(m.Data.Type(), m.Data) {
+			event.Publish(event.Default, m)
+			fanOut(m)
+		}
+		return
+	}
+
+	slot := v.(*sticky)
+	slot.mu.Lock()
+	slot.value = m
+	event.Publish(event.Default, m)
+	fanOut(m)
+	slot.mu.Unlock()
+}
@@ -0,0 +1,54 @@
+package emit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnSticky(t *testing.T) {
+	Retain[Phase]()
+
+	// Publish before anyone is subscribed
+	first := make(chan Phase)
+	defer On(func(ev Phase, now time.Time, elapsed time.Duration) error {
+		first <- ev
+		return nil
+	})()
+
+	Next(Phase{Name: "lobby"})
+	assert.Equal(t, "lobby", (<-first).Name)
+
+	// A late subscriber should receive the last event immediately
+	events := make(chan Phase, 2)
+	defer OnSticky(func(ev Phase, now time.Time, elapsed time.Duration) error {
+		events <- ev
+		return nil
+	})()
+	assert.Equal(t, "lobby", (<-events).Name)
+
+	// And then continue normally
+	Next(Phase{Name: "playing"})
+	assert.Equal(t, "playing", (<-first).Name)
+	assert.Equal(t, "playing", (<-events).Name)
+}
+
+func TestOnStickyNotRetained(t *testing.T) {
+	events := make(chan MyEvent1, 1)
+	defer OnSticky(func(ev MyEvent1, now time.Time, elapsed time.Duration) error {
+		events <- ev
+		return nil
+	})()
+
+	Next(MyEvent1{Number: 1})
+	assert.Equal(t, 1, (<-events).Number)
+}
+
+// ------------------------------------- Test Events -------------------------------------
+
+type Phase struct {
+	Name string
+}
+
+func (Phase) Type() uint32 { return 0x100 }
@@ -0,0 +1,32 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package emit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kelindar/event"
+)
+
+// OnSampled subscribes to an event, similarly to On, but only delivers an event if at least
+// 'minInterval' has elapsed since the last event delivered to this subscriber, dropping the
+// events which arrive faster. The first event is always delivered, and the interval is measured
+// between the times the events were emitted at.
+func OnSampled[T event.Event](minInterval time.Duration, handler func(event T, now time.Time, elapsed time.Duration) error, options ...Option) context.CancelFunc {
+	var mu sync.Mutex
+	var last time.Time
+	return On(func(ev T, now time.Time, elapsed time.Duration) error {
+		mu.Lock()
+		if !last.IsZero() && now.Sub(last) < minInterval {
+			mu.Unlock()
+			return nil
+		}
+
+		last = now
+		mu.Unlock()
+		return handler(ev, now, elapsed)
+	}, options...)
+}
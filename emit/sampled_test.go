@@ -0,0 +1,26 @@
+package emit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnSampled(t *testing.T) {
+	var received []int
+	defer OnSampled(100*time.Millisecond, func(ev Sampled, now time.Time, elapsed time.Duration) error {
+		received = append(received, ev.ID)
+		return nil
+	})()
+
+	// Only the first event of every interval is delivered
+	start := Scheduler.Now()
+	for i := 0; i < 30; i++ {
+		At(Sampled{ID: i}, start.Add(time.Duration(20+10*i)*time.Millisecond))
+	}
+
+	assert.NoError(t, Flush(context.Background()))
+	assert.Equal(t, []int{0, 10, 20}, received)
+}
@@ -0,0 +1,137 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package emit
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kelindar/event"
+)
+
+// errorWindow contains the window during which the identical errors are coalesced
+var errorWindow atomic.Int64
+
+func init() {
+	errorWindow.Store(int64(time.Second))
+}
+
+// faults contains the errors reported during their current window
+var faults struct {
+	sync.Mutex
+	seen map[faultKey]*tally
+}
+
+// tally represents an error which was seen during its window, which expires at a given time
+type tally struct {
+	*Repeated
+	about   any       // The value the error is about
+	expires time.Time // The end of the window
+}
+
+// faultKey represents the identity of an error, along with the type of value it is about
+type faultKey struct {
+	text  string
+	about string
+}
+
+// Repeated represents an error which was reported several times during a window, of which
+// only the first one was written immediately. It is written at the end of the window with
+// the number of times the error was repeated after the first one.
+type Repeated struct {
+	Err   error // The error which was repeated
+	Count int   // The number of repetitions, after the first error
+}
+
+// Error returns the text of the repeated error, along with the number of repetitions
+func (e *Repeated) Error() string {
+	return fmt.Sprintf("%v (repeated %d times)", e.Err, e.Count)
+}
+
+// Unwrap returns the error which was repeated
+func (e *Repeated) Unwrap() error {
+	return e.Err
+}
+
+// SetErrorWindow sets the window during which the identical errors written with Error are
+// coalesced, so that a broken handler failing on every event does not flood the subscribers
+// of OnError and the logs. The first error is written immediately, and the repetitions are
+// counted and written once at the end of the window as a Repeated error. The errors are
+// identical if they have the same text and are about the same type of value. The window is
+// one second by default, and zero disables the coalescing.
+func SetErrorWindow(window time.Duration) {
+	errorWindow.Store(int64(window))
+}
+
+// throttle returns whether the error should be written now, or counts it as a repetition
+func throttle(err error, about any) bool {
+	window := time.Duration(errorWindow.Load())
+	if window <= 0 {
+		return true
+	}
+
+	key := faultKey{text: err.Error(), about: fmt.Sprintf("%T", about)}
+	if ev, ok := about.(event.Event); ok {
+		key.about = fmt.Sprintf("%T/%x", about, ev.Type())
+	}
+
+	now := time.Now()
+	faults.Lock()
+	expired := sweep(now)
+	r, ok := faults.seen[key]
+	if ok {
+		r.Count++
+	} else {
+		if faults.seen == nil {
+			faults.seen = make(map[faultKey]*tally)
+		}
+
+		r = &tally{Repeated: &Repeated{Err: err}, about: about, expires: now.Add(window)}
+		faults.seen[key] = r
+	}
+	faults.Unlock()
+
+	// Write the repetitions of the errors whose window has ended
+	for _, f := range expired {
+		report(f.Repeated, f.about)
+	}
+
+	// Write the repetitions, if any, at the end of the window. Should the scheduler be replaced
+	// or the job dropped, the fault is swept the next time an error is written.
+	if !ok {
+		Scheduler.RunAfter(func(time.Time, time.Duration) bool {
+			faults.Lock()
+			if faults.seen[key] != r {
+				faults.Unlock()
+				return false
+			}
+
+			delete(faults.seen, key)
+			faults.Unlock()
+			if r.Count > 0 {
+				report(r.Repeated, r.about)
+			}
+			return false
+		}, window)
+	}
+	return !ok
+}
+
+// sweep removes the faults whose window has ended and returns those which were repeated. It
+// must be called with the lock held.
+func sweep(now time.Time) (repeated []*tally) {
+	for key, f := range faults.seen {
+		if now.Before(f.expires) {
+			continue
+		}
+
+		delete(faults.seen, key)
+		if f.Count > 0 {
+			repeated = append(repeated, f)
+		}
+	}
+	return
+}
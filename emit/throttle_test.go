@@ -0,0 +1,85 @@
+package emit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kelindar/timeline"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorWindow(t *testing.T) {
+	SetErrorWindow(50 * time.Millisecond)
+	defer SetErrorWindow(time.Second)
+
+	errs := make(chan error, 4)
+	defer OnError(func(err error, about any) {
+		errs <- err
+	})()
+
+	// Only the first of the identical errors is written immediately
+	flood := errors.New("flood")
+	for i := 0; i < 10; i++ {
+		Error(flood, Dynamic{ID: 53})
+	}
+	Error(flood, "about something else")
+	assert.Equal(t, flood, <-errs)
+	assert.Equal(t, flood, <-errs)
+
+	// The repetitions are written at the end of the window
+	var repeated *Repeated
+	assert.ErrorAs(t, <-errs, &repeated)
+	assert.Equal(t, 9, repeated.Count)
+	assert.ErrorIs(t, repeated, flood)
+	assert.Equal(t, "flood (repeated 9 times)", repeated.Error())
+	assert.Empty(t, errs)
+}
+
+func TestErrorWindowDisabled(t *testing.T) {
+	SetErrorWindow(0)
+	defer SetErrorWindow(time.Second)
+
+	errs := make(chan error, 4)
+	defer OnError(func(err error, about any) {
+		errs <- err
+	})()
+
+	for i := 0; i < 3; i++ {
+		Error(errors.New("flood"), nil)
+	}
+	for i := 0; i < 3; i++ {
+		assert.EqualError(t, <-errs, "flood")
+	}
+}
+
+func TestErrorWindowDropped(t *testing.T) {
+	SetErrorWindow(20 * time.Millisecond)
+	defer SetErrorWindow(time.Second)
+
+	// The scheduler is never started, so the end of the window is never run
+	previous := Scheduler
+	Scheduler = timeline.New()
+	defer func() { Scheduler = previous }()
+
+	errs := make(chan error, 4)
+	defer OnError(func(err error, about any) {
+		errs <- err
+	})()
+
+	flood := errors.New("dropped")
+	for i := 0; i < 3; i++ {
+		Error(flood, Dynamic{ID: 74})
+	}
+	assert.Equal(t, flood, <-errs)
+
+	// The expired fault is swept when the next error is written
+	time.Sleep(30 * time.Millisecond)
+	Error(flood, Dynamic{ID: 74})
+
+	var repeated *Repeated
+	assert.ErrorAs(t, <-errs, &repeated)
+	assert.Equal(t, 2, repeated.Count)
+	assert.Equal(t, flood, <-errs)
+	assert.Empty(t, errs)
+}
@@ -0,0 +1,61 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package emit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kelindar/event"
+)
+
+// Topic represents a typed topic, binding an event type identifier to the Go type of
+// the event so that the subscriptions and the writes of the topic can't be mismatched.
+type Topic[T event.Event] struct {
+	id uint32
+}
+
+// NewTopic returns a topic for the events of type T with the specified event type.
+func NewTopic[T event.Event](eventType uint32) Topic[T] {
+	return Topic[T]{id: eventType}
+}
+
+// Type returns the event type of the topic
+func (t Topic[T]) Type() uint32 {
+	return t.id
+}
+
+// On subscribes to the events of the topic.
+func (t Topic[T]) On(handler func(event T, now time.Time, elapsed time.Duration) error, options ...Option) context.CancelFunc {
+	return OnType(t.id, handler, options...)
+}
+
+// Next writes an event of the topic during the next tick.
+func (t Topic[T]) Next(ev T) {
+	Next(t.check(ev))
+}
+
+// At writes an event of the topic at specific 'at' time.
+func (t Topic[T]) At(ev T, at time.Time) {
+	At(t.check(ev), at)
+}
+
+// After writes an event of the topic after a 'delay'.
+func (t Topic[T]) After(ev T, after time.Duration) {
+	After(t.check(ev), after)
+}
+
+// Every writes an event of the topic at 'interval' intervals, starting at the next boundary tick.
+func (t Topic[T]) Every(ev T, interval time.Duration) {
+	Every(t.check(ev), interval)
+}
+
+// check panics if the type of the event does not match the topic
+func (t Topic[T]) check(ev T) T {
+	if typ := ev.Type(); typ != t.id {
+		panic(fmt.Errorf("emit: event type 0x%x does not match the topic 0x%x", typ, t.id))
+	}
+	return ev
+}
@@ -0,0 +1,35 @@
+package emit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopic(t *testing.T) {
+	topic := NewTopic[Dynamic](48)
+	assert.Equal(t, uint32(48), topic.Type())
+
+	events := make(chan Dynamic, 4)
+	defer topic.On(func(ev Dynamic, now time.Time, elapsed time.Duration) error {
+		events <- ev
+		return nil
+	})()
+
+	topic.Next(Dynamic{ID: 48})
+	assert.Equal(t, Dynamic{ID: 48}, <-events)
+
+	topic.After(Dynamic{ID: 48}, 10*time.Millisecond)
+	assert.Equal(t, Dynamic{ID: 48}, <-events)
+
+	topic.At(Dynamic{ID: 48}, time.Now())
+	assert.Equal(t, Dynamic{ID: 48}, <-events)
+}
+
+func TestTopicMismatch(t *testing.T) {
+	topic := NewTopic[Dynamic](49)
+	assert.Panics(t, func() {
+		topic.Next(Dynamic{ID: 50})
+	})
+}
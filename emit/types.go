@@ -0,0 +1,75 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package emit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kelindar/event"
+)
+
+// fanin dispatches the events to the handlers subscribed to several event types. Since these
+// handlers only know the events as event.Event, they can't share the typed subscriber groups
+// of the default dispatcher and are given a dispatcher of their own.
+var fanin = event.NewDispatcher()
+
+// fanned counts the handlers subscribed to several event types, map[uint32]*atomic.Int64
+var fanned sync.Map
+
+// OnTypes subscribes a single handler to several event types, for a handler which switches
+// on the type of the event internally. It returns a cancel function which unsubscribes the
+// handler from all of the event types at once.
+func OnTypes(types []uint32, handler func(ev event.Event, now time.Time, elapsed time.Duration) error) context.CancelFunc {
+	handler = recovered(handlerOf(handler))
+	cancels := make([]func(), 0, len(types))
+	for _, eventType := range types {
+		v, _ := fanned.LoadOrStore(eventType, new(atomic.Int64))
+		count := v.(*atomic.Int64)
+		count.Add(1)
+
+		untrack := track(eventType)
+		self := flushable[event.Event](fanin, eventType)
+		cancel := event.SubscribeTo(fanin, eventType, func(m signal[event.Event]) {
+			switch {
+			case m.barrier == nil:
+				if err := handler(m.Data, m.Time, m.Elapsed); err != nil {
+					Error(err, m.Data)
+				}
+			case m.barrier.owner == self:
+				close(m.barrier.done)
+			}
+		})
+
+		unflush := self.enable()
+		cancels = append(cancels, func() {
+			untrack()
+			unflush()
+			count.Add(-1)
+			cancel()
+		})
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			for _, cancel := range cancels {
+				cancel()
+			}
+		})
+	}
+}
+
+// fanOut writes the signal to the handlers subscribed to several event types, if any
+func fanOut[T event.Event](m signal[T]) {
+	if v, ok := fanned.Load(m.Data.Type()); ok && v.(*atomic.Int64).Load() > 0 {
+		event.Publish(fanin, signal[event.Event]{
+			Data:    m.Data,
+			Time:    m.Time,
+			Elapsed: m.Elapsed,
+		})
+	}
+}
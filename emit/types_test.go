@@ -0,0 +1,81 @@
+package emit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kelindar/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnTypes(t *testing.T) {
+	events := make(chan event.Event, 8)
+	cancel := OnTypes([]uint32{70, 71}, func(ev event.Event, now time.Time, elapsed time.Duration) error {
+		events <- ev
+		return nil
+	})
+
+	// Also delivered alongside a typed subscriber
+	typed := make(chan Dynamic, 8)
+	defer OnType(70, func(ev Dynamic, now time.Time, elapsed time.Duration) error {
+		typed <- ev
+		return nil
+	})()
+
+	Next(Dynamic{ID: 70})
+	assert.Equal(t, Dynamic{ID: 70}, <-events)
+	assert.Equal(t, Dynamic{ID: 70}, <-typed)
+
+	Next(Dynamic{ID: 71})
+	assert.Equal(t, Dynamic{ID: 71}, <-events)
+
+	// Unsubscribes from all of the types at once
+	cancel()
+	cancel()
+	Next(Dynamic{ID: 70})
+	Next(Dynamic{ID: 71})
+	assert.Equal(t, Dynamic{ID: 70}, <-typed)
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, events)
+}
+
+func TestOnTypesError(t *testing.T) {
+	errors := make(chan error, 8)
+	defer OnError(func(err error, about any) {
+		if ev, ok := about.(Dynamic); ok && ev.ID == 72 {
+			errors <- err
+		}
+	})()
+
+	defer OnTypes([]uint32{72}, func(ev event.Event, now time.Time, elapsed time.Duration) error {
+		return fmt.Errorf("OnTypes()")
+	})()
+
+	Next(Dynamic{ID: 72})
+	assert.Equal(t, "OnTypes()", (<-errors).Error())
+}
+
+func TestOnTypesPanic(t *testing.T) {
+	errors := make(chan any, 1)
+	defer OnError(func(err error, about any) {
+		errors <- about
+	})()
+
+	// A panic is reported and the handler keeps receiving events
+	calls, events := 0, make(chan event.Event, 1)
+	defer OnTypes([]uint32{73}, func(ev event.Event, now time.Time, elapsed time.Duration) error {
+		if calls++; calls == 1 {
+			panic("boom")
+		}
+
+		events <- ev
+		return nil
+	})()
+
+	Next(Dynamic{ID: 73})
+	assert.Equal(t, Dynamic{ID: 73}, <-errors)
+
+	Next(Dynamic{ID: 73})
+	assert.Equal(t, Dynamic{ID: 73}, <-events)
+}
@@ -0,0 +1,71 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package emit
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/kelindar/event"
+)
+
+// subscribers contains the number of subscribers of each event type
+var subscribers sync.Map // map[uint32]*atomic.Int64
+
+// unhandled contains the optional handler of the events without subscribers
+var unhandled atomic.Pointer[func(ev event.Event)]
+
+// OnUnhandled registers a handler which is invoked whenever an event is written while
+// there is no subscriber for its type, instead of the event silently vanishing. This is
+// useful during development to catch mistakes in the event types. The handler is invoked
+// on the scheduler's goroutine and should not block. Passing nil removes the handler.
+func OnUnhandled(handler func(ev event.Event)) {
+	if handler == nil {
+		unhandled.Store(nil)
+		return
+	}
+
+	unhandled.Store(&handler)
+}
+
+// track counts a subscriber of the event type and returns a function to untrack it
+func track(eventType uint32) func() {
+	v, _ := subscribers.LoadOrStore(eventType, new(atomic.Int64))
+	count := v.(*atomic.Int64)
+	count.Add(1)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			count.Add(-1)
+		})
+	}
+}
+
+// Subscriptions returns the number of handlers currently subscribed to each event type, keyed
+// by the event type. The types without any subscriber are omitted. This can be used to expose
+// the subscriptions on a debug endpoint and to catch the handlers which are never cancelled.
+func Subscriptions() map[uint32]int {
+	out := make(map[uint32]int)
+	subscribers.Range(func(k, v any) bool {
+		if n := v.(*atomic.Int64).Load(); n > 0 {
+			out[k.(uint32)] = int(n)
+		}
+		return true
+	})
+	return out
+}
+
+// handled returns whether the event type has subscribers, otherwise the event is forwarded
+// to the handler registered with OnUnhandled, if any.
+func handled(eventType uint32, ev event.Event) bool {
+	if v, ok := subscribers.Load(eventType); ok && v.(*atomic.Int64).Load() > 0 {
+		return true
+	}
+
+	if fn := unhandled.Load(); fn != nil {
+		(*fn)(ev)
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package emit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kelindar/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnUnhandled(t *testing.T) {
+	dead := make(chan event.Event, 8)
+	OnUnhandled(func(ev event.Event) {
+		if ev.Type() == 48 {
+			dead <- ev
+		}
+	})
+	defer OnUnhandled(nil)
+
+	// Nobody is subscribed yet
+	Next(Dynamic{ID: 48})
+	assert.Equal(t, Dynamic{ID: 48}, <-dead)
+
+	// Once subscribed, the event is delivered
+	events := make(chan Dynamic, 1)
+	cancel := OnType(48, func(ev Dynamic, now time.Time, elapsed time.Duration) error {
+		events <- ev
+		return nil
+	})
+
+	Next(Dynamic{ID: 48})
+	assert.Equal(t, Dynamic{ID: 48}, <-events)
+	assert.Empty(t, dead)
+
+	// And unhandled again once unsubscribed
+	cancel()
+	cancel()
+	Next(Dynamic{ID: 48})
+	assert.Equal(t, Dynamic{ID: 48}, <-dead)
+}
+
+func TestSubscriptions(t *testing.T) {
+	assert.NotContains(t, Subscriptions(), uint32(54))
+
+	handler := func(ev Dynamic, now time.Time, elapsed time.Duration) error { return nil }
+	cancel1 := OnType(54, handler)
+	cancel2 := OnType(54, handler)
+	assert.Equal(t, 2, Subscriptions()[54])
+
+	cancel1()
+	cancel1()
+	assert.Equal(t, 1, Subscriptions()[54])
+
+	cancel2()
+	assert.NotContains(t, Subscriptions(), uint32(54))
+}
@@ -0,0 +1,85 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package emit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kelindar/event"
+)
+
+// WindowOption represents an option of a windowed subscription.
+type WindowOption func(*window)
+
+// window represents the configuration of a windowed subscription.
+type window struct {
+	flushEmpty bool // Invoke the handler even if no event was received during the window
+}
+
+// FlushEmpty is a windowed subscription option which invokes the handler with an empty
+// batch for the windows during which no event was received.
+var FlushEmpty WindowOption = func(w *window) {
+	w.flushEmpty = true
+}
+
+// OnWindow subscribes to an event and accumulates the events of type T received during a
+// window of 'interval', delivering them to the handler as a single batch at the end of every
+// window. By default, the handler is not invoked for the windows without any event.
+func OnWindow[T event.Event](interval time.Duration, handler func(batch []T, now time.Time) error, options ...WindowOption) context.CancelFunc {
+	var w window
+	for _, opt := range options {
+		opt(&w)
+	}
+
+	var mu sync.Mutex
+	var batch []T
+	cancel := On(func(ev T, now time.Time, elapsed time.Duration) error {
+		mu.Lock()
+		defer mu.Unlock()
+		batch = append(batch, ev)
+		return nil
+	})
+
+	// Flush the accumulated events at the end of every window
+	var stop atomic.Bool
+	Scheduler.RunEvery(func(now time.Time, elapsed time.Duration) bool {
+		if stop.Load() {
+			return false
+		}
+
+		mu.Lock()
+		flush := batch
+		batch = nil
+		mu.Unlock()
+
+		if len(flush) == 0 && !w.flushEmpty {
+			return true
+		}
+
+		if err := flushWindow(handler, flush, now); err != nil {
+			Error(err, flush)
+		}
+		return true
+	}, interval)
+
+	return func() {
+		cancel()
+		stop.Store(true)
+	}
+}
+
+// flushWindow delivers the batch to the handler, converting a panic into an error so that
+// it is reported through OnError rather than stopping the scheduler, similarly to recovered.
+func flushWindow[T event.Event](handler func(batch []T, now time.Time) error, batch []T, now time.Time) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			var ev T
+			err = panicked(ev.Type(), r)
+		}
+	}()
+	return handler(batch, now)
+}
@@ -0,0 +1,93 @@
+package emit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnWindow(t *testing.T) {
+	batches := make(chan []Metric, 8)
+	defer OnWindow(100*time.Millisecond, func(batch []Metric, now time.Time) error {
+		batches <- batch
+		return nil
+	})()
+
+	NextBatch([]Metric{{Value: 1}, {Value: 2}, {Value: 3}})
+	var received []Metric
+	for len(received) < 3 {
+		received = append(received, <-batches...)
+	}
+	assert.Equal(t, []Metric{{Value: 1}, {Value: 2}, {Value: 3}}, received)
+
+	// Empty windows are not delivered
+	time.Sleep(250 * time.Millisecond)
+	assert.Empty(t, batches)
+}
+
+func TestOnWindowFlushEmpty(t *testing.T) {
+	batches := make(chan []Metric, 8)
+	defer OnWindow(50*time.Millisecond, func(batch []Metric, now time.Time) error {
+		batches <- batch
+		return nil
+	}, FlushEmpty)()
+
+	assert.Empty(t, <-batches)
+	assert.Empty(t, <-batches)
+}
+
+func TestOnWindowError(t *testing.T) {
+	errors := make(chan error, 8)
+	defer OnError(func(err error, about any) {
+		if _, ok := about.([]Metric); ok {
+			errors <- err
+		}
+	})()
+
+	defer OnWindow(50*time.Millisecond, func(batch []Metric, now time.Time) error {
+		return fmt.Errorf("OnWindow()")
+	})()
+
+	Next(Metric{Value: 1})
+	assert.Equal(t, "OnWindow()", (<-errors).Error())
+}
+
+func TestOnWindowPanic(t *testing.T) {
+	errors := make(chan error, 8)
+	defer OnError(func(err error, about any) {
+		if _, ok := about.([]Sample); ok {
+			errors <- err
+		}
+	})()
+
+	// The panic is reported and the next windows are still delivered
+	batches := make(chan []Sample, 8)
+	defer OnWindow(20*time.Millisecond, func(batch []Sample, now time.Time) error {
+		if batch[0].Value == 0 {
+			panic("boom")
+		}
+
+		batches <- batch
+		return nil
+	})()
+
+	Next(Sample{Value: 0})
+	assert.Equal(t, "emit: handler of event type 0x112 panicked: boom", (<-errors).Error())
+
+	Next(Sample{Value: 1})
+	assert.Equal(t, []Sample{{Value: 1}}, <-batches)
+}
+
+type Sample struct {
+	Value int
+}
+
+func (Sample) Type() uint32 { return 0x112 }
+
+type Metric struct {
+	Value int
+}
+
+func (Metric) Type() uint32 { return 0x10b }
@@ -11,7 +11,7 @@ import (
 func main() {
 	// Initialize the scheduler and start the internal clock
 	scheduler := timeline.New()
-	cancel := scheduler.Start(context.Background())
+	cancel, _ := scheduler.Start(context.Background())
 	defer cancel() // Call this to stop the scheduler's internal clock
 
 	// Define a task
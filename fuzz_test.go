@@ -0,0 +1,56 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func FuzzSchedule(f *testing.F) {
+	f.Add(int64(0), uint8(10))
+	f.Add(int64(42), uint8(200))
+	f.Fuzz(func(t *testing.T, seed int64, n uint8) {
+		const window = 300 // number of ticks to advance
+		rng := rand.New(rand.NewSource(seed))
+		now := time.Unix(100, 0)
+
+		// Every job must fire exactly on the tick it was due
+		s := newScheduler(now)
+		s.onFire = func(now, due tick) {
+			assert.Equal(t, due, now)
+		}
+
+		fired := make([]int, n)
+		expect := make([]int, n)
+		for i := range fired {
+			i := i
+			delay := rng.Intn(2 * window)
+			every := 0
+			if rng.Intn(2) == 0 {
+				every = 1 + rng.Intn(window/2)
+			}
+
+			switch {
+			case delay >= window:
+				expect[i] = 0
+			case every == 0:
+				expect[i] = 1
+			default:
+				expect[i] = (window-1-delay)/every + 1
+			}
+
+			s.Schedule(func(time.Time, time.Duration) bool {
+				fired[i]++
+				return true
+			}, now.Add(time.Duration(delay)*resolution), time.Duration(every)*resolution)
+		}
+
+		s.Advance(window * resolution)
+		assert.Equal(t, expect, fired)
+	})
+}
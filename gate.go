@@ -0,0 +1,40 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// RateGate represents a gate which lets a task through at most once per period of the
+// scheduler's clock, shared by all of its callers. This can be used to deduplicate the
+// same expensive work triggered from many places. It is safe for concurrent use.
+type RateGate struct {
+	period time.Duration
+	next   atomic.Int64 // time at which the gate opens again, in unix nanoseconds
+}
+
+// NewRateGate returns a new gate which lets a task through at most once per 'period'.
+func NewRateGate(period time.Duration) *RateGate {
+	return &RateGate{period: period}
+}
+
+// Run schedules the task on the next tick of the scheduler if the gate is open, and closes
+// the gate for the period. Otherwise, the task is dropped. It returns whether the task was
+// scheduled.
+func (g *RateGate) Run(s *Scheduler, task Task) bool {
+	now := s.Now().UnixNano()
+	for {
+		next := g.next.Load()
+		if now < next {
+			return false
+		}
+
+		if g.next.CompareAndSwap(next, now+int64(g.period)) {
+			s.Run(task)
+			return true
+		}
+	}
+}
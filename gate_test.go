@@ -0,0 +1,42 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateGate(t *testing.T) {
+	now := time.Unix(0, 0)
+	var count Counter
+
+	s := newScheduler(now)
+	gate := NewRateGate(time.Second)
+
+	// Only one of the concurrent callers gets through
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gate.Run(s, count.Inc())
+		}()
+	}
+
+	wg.Wait()
+	s.Tick()
+	assert.Equal(t, 1, count.Value())
+
+	// The gate stays closed for the period
+	s.Advance(980 * time.Millisecond)
+	assert.False(t, gate.Run(s, count.Inc()))
+	s.Tick()
+	assert.True(t, gate.Run(s, count.Inc()))
+	s.Tick()
+	assert.Equal(t, 2, count.Value())
+}
@@ -1,6 +1,6 @@
 module github.com/kelindar/timeline
 
-go 1.20
+go 1.21
 
 require (
 	github.com/kelindar/event v1.4.1
@@ -0,0 +1,344 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// State represents the state of a scheduled job.
+type State uint32
+
+// Various states of a scheduled job
+const (
+	Unscheduled State = iota // The job was never scheduled
+	Pending                  // The job is waiting to be executed
+	Running                  // The job is currently being executed
+	Done                     // The job has completed and will not run again
+	Cancelled                // The job was cancelled before it completed
+	Suspended                // The job is kept in the wheel but is not executed
+)
+
+// String returns the string representation of the state
+func (s State) String() string {
+	switch s {
+	case Unscheduled:
+		return "unscheduled"
+	case Pending:
+		return "pending"
+	case Running:
+		return "running"
+	case Done:
+		return "done"
+	case Cancelled:
+		return "cancelled"
+	case Suspended:
+		return "suspended"
+	default:
+		return "unknown"
+	}
+}
+
+// Handle represents a handle to a scheduled job, which can be used to inspect its
+// state or cancel it. The state transitions are safe to observe concurrently with
+// the execution of the job.
+type Handle struct {
+	state atomic.Uint32
+	mu    sync.Mutex
+	then  []func(State) // continuations to run once the job is settled, see OnSettled
+	wait  chan struct{} // closed once the job is done or cancelled, see Wait
+}
+
+// ErrCancelled is returned by Wait when the job was cancelled rather than done.
+var ErrCancelled = errors.New("timeline: job was cancelled")
+
+// ErrRejected is returned when a job is rejected because the maximum number of pending jobs
+// is reached, see WithMaxJobs.
+var ErrRejected = errors.New("timeline: job was rejected, too many pending jobs")
+
+// Schedule schedules a task to run at a specific 'at' time and, if 'every' is not zero,
+// at 'every' intervals afterwards. It returns a handle to track or cancel the job.
+func (s *Scheduler) Schedule(task Task, at time.Time, every time.Duration) *Handle {
+	handle := new(Handle)
+	handle.state.Store(uint32(Pending))
+
+	when := tickOf(at)
+	if every != 0 && s.smoothing > 0 {
+		when = s.leastLoaded(when)
+	}
+
+	if !s.enqueueJob(job{
+		Task:   task,
+		RunAt:  when,
+		Since:  span(when - s.now()),
+		Every:  durationOf(every),
+		Handle: handle,
+	}) {
+		handle.Cancel() // rejected, see WithMaxJobs
+	}
+	return handle
+}
+
+// SchedulePriority schedules a task to run once at a specific 'at' time, before the tasks with
+// a lower priority which are due on the same tick, similarly to RunAtPriority. It returns a
+// handle to track or cancel the job.
+func (s *Scheduler) SchedulePriority(task Task, at time.Time, priority uint8) *Handle {
+	handle := new(Handle)
+	handle.state.Store(uint32(Pending))
+
+	when := tickOf(at)
+	if !s.enqueueJob(job{
+		Task:   task,
+		RunAt:  when,
+		Since:  span(when - s.now()),
+		Handle: handle,
+		Seq:    uint64(priority) << priorityShift,
+	}) {
+		handle.Cancel() // rejected, see WithMaxJobs
+	}
+	return handle
+}
+
+// RunAfterJob schedules a task to run once, a 'delay' after the job of the 'dep' handle is done,
+// which is after its last execution. Until then, the task is not queued in the wheel at all. If
+// the job is already done, the task is scheduled after the delay from the last processed tick,
+// and if the job is cancelled instead, the task never runs.
+func (s *Scheduler) RunAfterJob(dep *Handle, task Task, delay time.Duration) {
+	if dep == nil {
+		s.RunAfter(task, delay)
+		return
+	}
+
+	// The job is done on the tick being processed, or before the last processed one
+	dep.onDone(func() {
+		at := s.now() - 1 + tick(durationOf(delay))
+		if at < s.now() {
+			at = s.now()
+		}
+		s.schedule(task, at, 0)
+	})
+}
+
+// RunAfterFunc schedules the function to run once after a 'delay', similarly to time.AfterFunc,
+// but on the scheduler's clock rather than a dedicated timer. The returned handle can be
+// stopped with Stop, which follows the contract of time.Timer.
+func (s *Scheduler) RunAfterFunc(delay time.Duration, fn func()) *Handle {
+	return s.Schedule(func(time.Time, time.Duration) bool {
+		fn()
+		return false
+	}, s.after(delay).Time(), 0)
+}
+
+// RunAfterWithResult schedules a task to run once after a 'delay' and returns a channel which
+// yields the value returned by the task once it has executed. If the job is rejected, see
+// WithMaxJobs, or cancelled before it has returned, the channel is closed without yielding a
+// value.
+func (s *Scheduler) RunAfterWithResult(task Task, delay time.Duration) <-chan bool {
+	var once sync.Once
+	result := make(chan bool, 1)
+	settle := func(value, ok bool) {
+		once.Do(func() {
+			if ok {
+				result <- value
+			}
+			close(result)
+		})
+	}
+
+	s.Schedule(func(now time.Time, elapsed time.Duration) bool {
+		settle(task(now, elapsed), true)
+		return false
+	}, s.after(delay).Time(), 0).OnSettled(func(State) {
+		settle(false, false) // rejected or cancelled, since a done job has already yielded its value
+	})
+	return result
+}
+
+// State returns the current state of the job. A nil handle is considered unscheduled.
+func (h *Handle) State() State {
+	if h == nil {
+		return Unscheduled
+	}
+
+	return State(h.state.Load())
+}
+
+// Cancel cancels the job, preventing any further execution. If the job is currently
+// running, the current execution completes but the job is not rescheduled. It returns
+// whether the job was cancelled by this call.
+func (h *Handle) Cancel() bool {
+	for {
+		switch state := h.state.Load(); State(state) {
+		case Pending, Running, Suspended:
+			if h.state.CompareAndSwap(state, uint32(Cancelled)) {
+				h.finish()
+				return true
+			}
+		default:
+			return false
+		}
+	}
+}
+
+// Stop prevents the job from firing, similarly to time.Timer.Stop. It returns true if the
+// call stops the job, or false if the job has already fired, been stopped or is currently
+// running, in which case the job is left untouched. Use Cancel to also prevent a recurring
+// job which is currently running from being rescheduled.
+func (h *Handle) Stop() bool {
+	switch {
+	case h == nil:
+		return false
+	case h.state.CompareAndSwap(uint32(Pending), uint32(Cancelled)),
+		h.state.CompareAndSwap(uint32(Suspended), uint32(Cancelled)):
+		h.finish()
+		return true
+	default:
+		return false
+	}
+}
+
+// begin transitions the job into the running state, returns false if cancelled.
+func (h *Handle) begin() bool {
+	return h.state.CompareAndSwap(uint32(Pending), uint32(Running))
+}
+
+// Suspend suspends the job, which is kept in the wheel but skipped until it is resumed. A
+// recurring job keeps its phase while suspended, and a one-shot job which became due runs
+// within a rotation of the wheel (a second) once resumed. If the job is currently running,
+// the current execution completes. It returns whether the job was suspended by this call.
+func (h *Handle) Suspend() bool {
+	for {
+		switch state := h.state.Load(); State(state) {
+		case Pending, Running:
+			if h.state.CompareAndSwap(state, uint32(Suspended)) {
+				return true
+			}
+		default:
+			return false
+		}
+	}
+}
+
+// Resume resumes a suspended job. A recurring job continues its cadence from its next
+// occurrence, without running the occurrences it missed. It returns whether the job was
+// resumed by this call.
+func (h *Handle) Resume() bool {
+	return h.state.CompareAndSwap(uint32(Suspended), uint32(Pending))
+}
+
+// Wait blocks until the job is done or cancelled, or until the context is done. It returns nil
+// once the job is done, ErrCancelled if it was cancelled, or the error of the context. A one-shot
+// job is done after it fired, while a recurring job is only done once its task returns false, so
+// waiting on a recurring job which is never stopped blocks until the context is done.
+func (h *Handle) Wait(ctx context.Context) error {
+	if h == nil {
+		return ErrCancelled
+	}
+
+	h.mu.Lock()
+	switch h.State() {
+	case Done:
+		h.mu.Unlock()
+		return nil
+	case Cancelled:
+		h.mu.Unlock()
+		return ErrCancelled
+	}
+
+	if h.wait == nil {
+		h.wait = make(chan struct{})
+	}
+	wait := h.wait
+	h.mu.Unlock()
+
+	select {
+	case <-wait:
+		if h.State() == Cancelled {
+			return ErrCancelled
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// OnSettled registers a function which is invoked with the final state of the job once it is
+// done or cancelled, or immediately if it already is. The function is invoked on the goroutine
+// settling the job, which is the scheduler's one unless the job is cancelled, and should not
+// block.
+func (h *Handle) OnSettled(fn func(State)) {
+	h.mu.Lock()
+	switch state := h.State(); state {
+	case Done, Cancelled:
+		h.mu.Unlock()
+		fn(state)
+	default:
+		h.then = append(h.then, fn)
+		h.mu.Unlock()
+	}
+}
+
+// onDone runs the continuation once the job is done, or immediately if it already is. The
+// continuation is dropped if the job is cancelled.
+func (h *Handle) onDone(fn func()) {
+	h.OnSettled(func(state State) {
+		if state == Done {
+			fn()
+		}
+	})
+}
+
+// finish runs the continuations of the job once it is settled.
+func (h *Handle) finish() {
+	h.mu.Lock()
+	then := h.then
+	h.then = nil
+	if h.wait != nil {
+		close(h.wait)
+		h.wait = nil
+	}
+	h.mu.Unlock()
+
+	state := h.State()
+	for _, fn := range then {
+		fn(state)
+	}
+}
+
+// end transitions the job out of the running state and returns whether the job
+// should be rescheduled. If the job was cancelled while running, it is not. If it
+// was suspended or resumed while running, it remains in that state.
+func (h *Handle) end(repeat bool) bool {
+	next := Done
+	if repeat {
+		next = Pending
+	}
+
+	for {
+		switch state := h.state.Load(); State(state) {
+		case Running:
+			if h.state.CompareAndSwap(state, uint32(next)) {
+				if !repeat {
+					h.finish()
+				}
+				return repeat
+			}
+		case Pending, Suspended:
+			if repeat {
+				return repeat
+			}
+			if h.state.CompareAndSwap(state, uint32(Done)) {
+				h.finish()
+				return repeat
+			}
+		default:
+			h.finish()
+			return false
+		}
+	}
+}
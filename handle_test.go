@@ -0,0 +1,354 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleOnce(t *testing.T) {
+	now := time.Unix(0, 0)
+	var count Counter
+
+	s := newScheduler(now)
+	h := s.Schedule(count.Inc(), now.Add(20*time.Millisecond), 0)
+
+	assert.Equal(t, Pending, h.State())
+	for i := 0; i < 5; i++ {
+		s.Tick()
+	}
+
+	assert.Equal(t, Done, h.State())
+	assert.Equal(t, 1, count.Value())
+	assert.False(t, h.Cancel())
+}
+
+func TestHandleRecurring(t *testing.T) {
+	now := time.Unix(0, 0)
+	var count Counter
+
+	s := newScheduler(now)
+	h := s.Schedule(count.Inc(), now, 10*time.Millisecond)
+	for i := 0; i < 5; i++ {
+		s.Tick()
+	}
+
+	assert.Equal(t, Pending, h.State())
+	assert.Equal(t, 5, count.Value())
+
+	// Cancel it, should not run again
+	assert.True(t, h.Cancel())
+	assert.False(t, h.Cancel())
+	for i := 0; i < 5; i++ {
+		s.Tick()
+	}
+
+	assert.Equal(t, Cancelled, h.State())
+	assert.Equal(t, 5, count.Value())
+}
+
+func TestHandleCancelWhileRunning(t *testing.T) {
+	now := time.Unix(0, 0)
+	var count Counter
+	var h *Handle
+
+	s := newScheduler(now)
+	h = s.Schedule(func(now time.Time, elapsed time.Duration) bool {
+		assert.Equal(t, Running, h.State())
+		assert.True(t, h.Cancel())
+		count.Inc()(now, elapsed)
+		return true
+	}, now, 10*time.Millisecond)
+
+	for i := 0; i < 5; i++ {
+		s.Tick()
+	}
+
+	assert.Equal(t, Cancelled, h.State())
+	assert.Equal(t, 1, count.Value())
+}
+
+func TestHandleStop(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newScheduler(now)
+	h := s.Schedule(func(now time.Time, elapsed time.Duration) bool {
+		return false
+	}, now, time.Second)
+
+	s.Tick()
+	assert.Equal(t, Done, h.State())
+}
+
+func TestHandleUnscheduled(t *testing.T) {
+	var h *Handle
+	assert.Equal(t, Unscheduled, h.State())
+	assert.Equal(t, Unscheduled, new(Handle).State())
+
+	for _, v := range []State{Unscheduled, Pending, Running, Done, Cancelled, Suspended} {
+		assert.NotEqual(t, "unknown", v.String())
+	}
+	assert.Equal(t, "unknown", State(99).String())
+}
+
+func TestRunAfterFunc(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newScheduler(now)
+
+	// Stopped before it fires
+	fired := 0
+	h1 := s.RunAfterFunc(50*time.Millisecond, func() { fired++ })
+	assert.True(t, h1.Stop())
+	assert.False(t, h1.Stop())
+
+	// Stopped after it fired
+	h2 := s.RunAfterFunc(50*time.Millisecond, func() { fired++ })
+	s.Advance(40 * time.Millisecond)
+	assert.Equal(t, 0, fired)
+	s.Advance(20 * time.Millisecond)
+	assert.Equal(t, 1, fired)
+	assert.False(t, h2.Stop())
+	assert.Equal(t, Done, h2.State())
+
+	var h3 *Handle
+	assert.False(t, h3.Stop())
+}
+
+func TestHandleStopWhileRunning(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newScheduler(now)
+
+	// Stopping the job from within its own function is a no-op
+	var h *Handle
+	stopped := true
+	h = s.RunAfterFunc(0, func() { stopped = h.Stop() })
+
+	var count Counter
+	s.RunAfterJob(h, count.Inc(), 0)
+	s.Advance(30 * time.Millisecond)
+	assert.False(t, stopped)
+	assert.Equal(t, Done, h.State())
+	assert.Equal(t, 1, count.Value())
+}
+
+func TestHandleSuspend(t *testing.T) {
+	now := time.Unix(0, 0)
+	var runs []time.Time
+
+	s := newScheduler(now)
+	h := s.Schedule(func(now time.Time, elapsed time.Duration) bool {
+		runs = append(runs, now)
+		return true
+	}, now.Add(100*time.Millisecond), 100*time.Millisecond)
+
+	s.Advance(150 * time.Millisecond)
+	assert.True(t, h.Suspend())
+	assert.False(t, h.Suspend())
+	assert.Equal(t, Suspended, h.State())
+
+	// Skipped while suspended, the missed occurrences are not replayed
+	s.Advance(300 * time.Millisecond)
+	assert.Len(t, runs, 1)
+	assert.True(t, h.Resume())
+	assert.False(t, h.Resume())
+
+	// Keeps the phase once resumed
+	s.Advance(200 * time.Millisecond)
+	assert.Equal(t, []time.Time{
+		now.Add(100 * time.Millisecond),
+		now.Add(500 * time.Millisecond),
+		now.Add(600 * time.Millisecond),
+	}, runs)
+
+	// A suspended job can still be cancelled
+	assert.True(t, h.Suspend())
+	assert.True(t, h.Stop())
+	assert.False(t, h.Resume())
+	assert.Equal(t, Cancelled, h.State())
+}
+
+func TestHandleSuspendOnce(t *testing.T) {
+	now := time.Unix(0, 0)
+	var count Counter
+
+	s := newScheduler(now)
+	h := s.Schedule(count.Inc(), now.Add(50*time.Millisecond), 0)
+	assert.True(t, h.Suspend())
+
+	// Kept in its bucket until resumed
+	s.Advance(2 * time.Second)
+	assert.Equal(t, 0, count.Value())
+	assert.Equal(t, []JobInfo{{
+		Bucket: 5,
+		RunAt:  now.Add(2050 * time.Millisecond),
+		Handle: h,
+	}}, s.Jobs())
+
+	// Runs when its bucket comes around again
+	assert.True(t, h.Resume())
+	s.Advance(50 * time.Millisecond)
+	assert.Equal(t, 0, count.Value())
+	s.Advance(10 * time.Millisecond)
+	assert.Equal(t, 1, count.Value())
+	assert.Equal(t, Done, h.State())
+}
+
+func TestHandleSuspendWhileRunning(t *testing.T) {
+	now := time.Unix(0, 0)
+	var count Counter
+	var h *Handle
+
+	s := newScheduler(now)
+	h = s.Schedule(func(now time.Time, elapsed time.Duration) bool {
+		assert.True(t, h.Suspend())
+		count.Inc()(now, elapsed)
+		return true
+	}, now, 10*time.Millisecond)
+
+	s.Advance(50 * time.Millisecond)
+	assert.Equal(t, Suspended, h.State())
+	assert.Equal(t, 1, count.Value())
+	assert.Len(t, s.Jobs(), 1)
+}
+
+func TestRunAfterJob(t *testing.T) {
+	now := time.Unix(0, 0)
+	var ticks []int64
+	record := func(now time.Time, elapsed time.Duration) bool {
+		ticks = append(ticks, now.UnixMilli())
+		return true
+	}
+
+	s := newScheduler(now)
+	dep := s.Schedule(record, now.Add(50*time.Millisecond), 0)
+	s.RunAfterJob(dep, record, 200*time.Millisecond)
+	assert.Len(t, s.Jobs(), 1)
+
+	// The task runs a delay after the dependency is done
+	s.Advance(time.Second)
+	assert.Equal(t, []int64{50, 250}, ticks)
+
+	// The dependency is already done
+	s.RunAfterJob(dep, record, 0)
+	s.Tick()
+	assert.Equal(t, []int64{50, 250, 1000}, ticks)
+}
+
+func TestRunAfterJobCancelled(t *testing.T) {
+	now := time.Unix(0, 0)
+	var count Counter
+
+	s := newScheduler(now)
+	dep := s.Schedule(count.Inc(), now.Add(50*time.Millisecond), 10*time.Millisecond)
+	s.RunAfterJob(dep, count.Inc(), 0)
+	s.RunAfterJob(nil, count.Inc(), 0)
+
+	dep.Cancel()
+	s.RunAfterJob(dep, count.Inc(), 0)
+	s.Advance(time.Second)
+	assert.Equal(t, 1, count.Value())
+}
+
+func TestHandleWait(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newScheduler(now)
+	h := s.RunAfterFunc(50*time.Millisecond, func() {})
+
+	waited := make(chan error, 1)
+	go func() { waited <- h.Wait(context.Background()) }()
+
+	s.Advance(100 * time.Millisecond)
+	assert.NoError(t, <-waited)
+	assert.NoError(t, h.Wait(context.Background()))
+}
+
+func TestHandleWaitCancelled(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newScheduler(now)
+	h := s.RunAfterFunc(50*time.Millisecond, func() {})
+
+	waited := make(chan error, 1)
+	go func() { waited <- h.Wait(context.Background()) }()
+
+	h.Cancel()
+	assert.ErrorIs(t, <-waited, ErrCancelled)
+	assert.ErrorIs(t, h.Wait(context.Background()), ErrCancelled)
+
+	var none *Handle
+	assert.ErrorIs(t, none.Wait(context.Background()), ErrCancelled)
+}
+
+func TestHandleWaitContext(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newScheduler(now)
+	h := s.Schedule(func(time.Time, time.Duration) bool { return true }, now, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	s.Advance(100 * time.Millisecond)
+	assert.ErrorIs(t, h.Wait(ctx), context.DeadlineExceeded)
+	assert.Equal(t, Pending, h.State())
+}
+
+func TestRunAfterWithResult(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newScheduler(now)
+	ok := s.RunAfterWithResult(func(time.Time, time.Duration) bool { return true }, 20*time.Millisecond)
+	fail := s.RunAfterWithResult(func(time.Time, time.Duration) bool { return false }, 20*time.Millisecond)
+
+	s.Advance(10 * time.Millisecond)
+	assert.Len(t, ok, 0)
+
+	s.Advance(20 * time.Millisecond)
+	assert.True(t, <-ok)
+	assert.False(t, <-fail)
+}
+
+func TestRunAfterWithResultRejected(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newScheduler(now, WithMaxJobs(1))
+	s.RunAfter(func(time.Time, time.Duration) bool { return false }, 0)
+
+	result, ok := <-s.RunAfterWithResult(func(time.Time, time.Duration) bool { return true }, 0)
+	assert.False(t, result)
+	assert.False(t, ok)
+}
+
+func TestRunAfterWithResultCancelled(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newScheduler(now)
+	r1 := s.RunAfterWithResult(func(time.Time, time.Duration) bool { return true }, 20*time.Millisecond)
+	r2 := s.RunAfterWithResult(func(time.Time, time.Duration) bool { return true }, 50*time.Millisecond)
+
+	// Cancelled after being scheduled, either by the scheduler or through the handle
+	assert.Equal(t, 1, s.CancelBefore(now.Add(30*time.Millisecond)))
+	s.Jobs()[0].Handle.Cancel()
+	s.Advance(100 * time.Millisecond)
+
+	_, ok := <-r1
+	assert.False(t, ok)
+	_, ok = <-r2
+	assert.False(t, ok)
+}
+
+func TestHandleOnSettled(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newScheduler(now)
+
+	var states []State
+	settled := func(state State) { states = append(states, state) }
+	h1 := s.RunAfterFunc(10*time.Millisecond, func() {})
+	h1.OnSettled(settled)
+	h2 := s.RunAfterFunc(10*time.Millisecond, func() {})
+	h2.OnSettled(settled)
+
+	h2.Cancel()
+	s.Advance(30 * time.Millisecond)
+	h1.OnSettled(settled)
+	assert.Equal(t, []State{Cancelled, Done, Done}, states)
+}
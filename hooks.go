@@ -0,0 +1,97 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// hooks represents the optional callbacks invoked around each tick.
+type hooks struct {
+	before   atomic.Pointer[func(now time.Time)]
+	after    atomic.Pointer[func(now time.Time, executed int)]
+	stop     atomic.Pointer[func(err error)]
+	slow     atomic.Pointer[slowTask]
+	complete atomic.Pointer[func(h *Handle)]
+}
+
+// slowTask represents the callback invoked for the tasks exceeding a threshold.
+type slowTask struct {
+	threshold time.Duration
+	fn        func(d time.Duration, task Task)
+}
+
+// BeforeTick registers a callback which is invoked exactly once at the beginning of
+// every tick, before any task is executed. Passing nil removes the callback.
+func (s *Scheduler) BeforeTick(fn func(now time.Time)) {
+	if fn == nil {
+		s.hooks.before.Store(nil)
+		return
+	}
+
+	s.hooks.before.Store(&fn)
+}
+
+// AfterTick registers a callback which is invoked exactly once at the end of every tick,
+// along with the number of tasks executed during that tick. Passing nil removes the callback.
+func (s *Scheduler) AfterTick(fn func(now time.Time, executed int)) {
+	if fn == nil {
+		s.hooks.after.Store(nil)
+		return
+	}
+
+	s.hooks.after.Store(&fn)
+}
+
+// OnStop registers a callback which is invoked when the internal clock started with Start
+// stops. The error is nil if the clock was cancelled, or describes the panic which caused
+// the clock to stop. Passing nil removes the callback.
+func (s *Scheduler) OnStop(fn func(err error)) {
+	if fn == nil {
+		s.hooks.stop.Store(nil)
+		return
+	}
+
+	s.hooks.stop.Store(&fn)
+}
+
+// OnComplete registers a callback which is invoked when a recurring job terminates, either
+// because its task returned false or because it was cancelled, so that the resources it is
+// associated with can be released. The callback is invoked by the tick at which the job is not
+// rescheduled, or by CancelWhere when it removes the job, with the handle of the job, or nil if
+// it was not scheduled with Schedule. Passing nil removes the callback.
+func (s *Scheduler) OnComplete(fn func(h *Handle)) {
+	if fn == nil {
+		s.hooks.complete.Store(nil)
+		return
+	}
+
+	s.hooks.complete.Store(&fn)
+}
+
+// OnSlowTask registers a callback which is invoked after every task whose execution took
+// longer than the 'threshold', along with the measured duration and the task itself. The
+// tasks are only timed while a callback is registered. Passing nil removes the callback.
+func (s *Scheduler) OnSlowTask(threshold time.Duration, fn func(d time.Duration, task Task)) {
+	if fn == nil {
+		s.hooks.slow.Store(nil)
+		return
+	}
+
+	s.hooks.slow.Store(&slowTask{
+		threshold: threshold,
+		fn:        fn,
+	})
+}
+
+// run runs the job and invokes the callback if it took longer than the threshold.
+func (t *slowTask) run(job *job, now time.Time, elapsed time.Duration) bool {
+	start := time.Now()
+	repeat := job.run(now, elapsed)
+	if d := time.Since(start); d > t.threshold {
+		t.fn(d, job.task())
+	}
+	return repeat
+}
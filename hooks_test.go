@@ -0,0 +1,121 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTickHooks(t *testing.T) {
+	now := time.Unix(0, 0)
+	log := make(Log, 0, 8)
+
+	s := newScheduler(now)
+	s.BeforeTick(func(now time.Time) {
+		log = append(log, fmt.Sprintf("before %d", now.UnixMilli()))
+	})
+	s.AfterTick(func(now time.Time, executed int) {
+		log = append(log, fmt.Sprintf("after %d (%d)", now.UnixMilli(), executed))
+	})
+
+	s.Run(log.Log("task 1"))
+	s.Run(log.Log("task 2"))
+	s.Tick()
+	s.Tick()
+
+	assert.Equal(t, Log{
+		"before 0",
+		"task 1",
+		"task 2",
+		"after 0 (2)",
+		"before 10",
+		"after 10 (0)",
+	}, log)
+
+	// Remove the hooks
+	s.BeforeTick(nil)
+	s.AfterTick(nil)
+	s.Tick()
+	assert.Len(t, log, 6)
+}
+
+func TestOnSlowTask(t *testing.T) {
+	now := time.Unix(0, 0)
+	var slow []time.Duration
+
+	s := newScheduler(now)
+	s.OnSlowTask(5*time.Millisecond, func(d time.Duration, task Task) {
+		assert.NotNil(t, task)
+		slow = append(slow, d)
+	})
+
+	s.Run(func(time.Time, time.Duration) bool { return true })
+	s.Run(func(time.Time, time.Duration) bool {
+		time.Sleep(10 * time.Millisecond)
+		return true
+	})
+	s.Tick()
+	assert.Len(t, slow, 1)
+	assert.GreaterOrEqual(t, slow[0], 10*time.Millisecond)
+
+	// Remove the callback
+	s.OnSlowTask(0, nil)
+	s.Run(func(time.Time, time.Duration) bool {
+		time.Sleep(10 * time.Millisecond)
+		return true
+	})
+	s.Tick()
+	assert.Len(t, slow, 1)
+}
+
+func TestOnComplete(t *testing.T) {
+	now := time.Unix(0, 0)
+	var completed []*Handle
+
+	s := newScheduler(now)
+	s.OnComplete(func(h *Handle) {
+		completed = append(completed, h)
+	})
+
+	// A recurring job which stops itself, one which is cancelled and a one-shot job
+	stop := s.Schedule(func(time.Time, time.Duration) bool {
+		return false
+	}, now, 10*time.Millisecond)
+	cancel := s.Schedule(func(time.Time, time.Duration) bool {
+		return true
+	}, now, 20*time.Millisecond)
+	s.Schedule(func(time.Time, time.Duration) bool {
+		return false
+	}, now, 0)
+
+	s.Tick()
+	assert.Equal(t, []*Handle{stop}, completed)
+
+	cancel.Cancel()
+	s.Advance(50 * time.Millisecond)
+	assert.Equal(t, []*Handle{stop, cancel}, completed)
+}
+
+func TestOnCompleteCancelWhere(t *testing.T) {
+	now := time.Unix(0, 0)
+	var completed []*Handle
+
+	s := newScheduler(now)
+	s.OnComplete(func(h *Handle) {
+		completed = append(completed, h)
+	})
+
+	// Only the removed recurring jobs are complete
+	every := s.Schedule(func(time.Time, time.Duration) bool { return true }, now.Add(time.Second), time.Second)
+	s.Schedule(func(time.Time, time.Duration) bool { return true }, now.Add(time.Second), 0)
+	s.RunEveryAfter(func(time.Time, time.Duration) bool { return true }, time.Second, 2*time.Second)
+	assert.Equal(t, 3, s.CancelBefore(now.Add(5*time.Second)))
+	assert.Len(t, completed, 2)
+	assert.Contains(t, completed, every)
+	assert.Contains(t, completed, (*Handle)(nil))
+}
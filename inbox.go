@@ -0,0 +1,133 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+const inboxSize = 1024 // number of tasks the inbox can hold, must be a power of 2
+
+// inbox represents a bounded, lock-free queue of the tasks scheduled with Run for the
+// next tick. Producers never block on a lock, while the consumers are serialized and
+// drain the tasks in the order in which they were enqueued.
+type inbox struct {
+	mu    sync.Mutex    // serializes the consumers
+	head  atomic.Uint64 // next position to dequeue
+	_     [48]byte      // padding to keep the producers off the consumer's cache line
+	tail  atomic.Uint64 // next position to enqueue
+	cells [inboxSize]cell
+}
+
+// cell represents a slot of the inbox, its sequence indicates whether it's available
+// for the producer at that position or ready for the consumer.
+type cell struct {
+	seq  atomic.Uint64
+	task Task
+}
+
+// init initializes the sequences of the cells.
+func (q *inbox) init() {
+	for i := range q.cells {
+		q.cells[i].seq.Store(uint64(i))
+	}
+}
+
+// push enqueues a task, returning false if the inbox is full.
+func (q *inbox) push(task Task) bool {
+	for {
+		pos := q.tail.Load()
+		cell := &q.cells[pos&(inboxSize-1)]
+		switch diff := int64(cell.seq.Load() - pos); {
+		case diff == 0 && q.tail.CompareAndSwap(pos, pos+1):
+			cell.task = task
+			cell.seq.Store(pos + 1)
+			return true
+		case diff < 0:
+			return false // the cell was not consumed yet
+		}
+	}
+}
+
+// pop dequeues a task, must be called while holding the lock. It returns false if
+// the inbox is empty, or if the next task is still being written by its producer.
+func (q *inbox) pop() (Task, bool) {
+	pos := q.head.Load()
+	cell := &q.cells[pos&(inboxSize-1)]
+	if cell.seq.Load() != pos+1 {
+		return nil, false
+	}
+
+	task := cell.task
+	cell.task = nil
+	cell.seq.Store(pos + inboxSize)
+	q.head.Store(pos + 1)
+	return task, true
+}
+
+// empty returns whether the inbox has no pending tasks.
+func (q *inbox) empty() bool {
+	return q.head.Load() == q.tail.Load()
+}
+
+// drain moves the tasks of the inbox into the bucket of the next tick. This is used
+// when the inbox is full, or before the buckets are inspected.
+func (s *Scheduler) drain() {
+	if s.inbox.empty() {
+		return
+	}
+
+	for {
+		at := s.now()
+		bucket := s.bucketOf(at)
+		bucket.mu.Lock()
+		if s.now() != at {
+			bucket.mu.Unlock()
+			continue // the bucket may have been processed already, try again
+		}
+
+		s.drainInto(bucket, at)
+		bucket.mu.Unlock()
+		return
+	}
+}
+
+// drainInto moves the tasks of the inbox into the bucket, must be called while holding
+// the lock of the bucket. The buckets are always locked before the inbox.
+func (s *Scheduler) drainInto(bucket *bucket, at tick) {
+	if s.inbox.empty() {
+		return
+	}
+
+	s.inbox.mu.Lock()
+	defer s.inbox.mu.Unlock()
+	for {
+		task, ok := s.inbox.pop()
+		if !ok {
+			return
+		}
+
+		bucket.queue = append(bucket.queue, job{
+			Task:  task,
+			RunAt: at,
+		})
+	}
+}
+
+// enqueue adds the task into the inbox. If the inbox is full, it is drained into the
+// bucket of the next tick. Since a producer which is still writing its task prevents
+// the tasks behind it from being drained, this yields until room is available.
+func (s *Scheduler) enqueue(task Task) {
+	if !s.admit() {
+		return
+	}
+
+	s.counters.scheduled.Add(1)
+	for !s.inbox.push(task) {
+		s.drain()
+		runtime.Gosched()
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"time"
+)
+
+// JobInfo represents a snapshot of a pending job.
+type JobInfo struct {
+	RunAt  time.Time     // When the job is due to run next
+	Every  time.Duration // How often the job runs, zero for one-shot jobs
+	Handle *Handle       // The handle of the job, nil if it was not scheduled with Schedule
+	Bucket int           // The index of the bucket the job is queued in
+}
+
+// infoOf returns the job information of a job
+func infoOf(job *job) JobInfo {
+	return JobInfo{
+		RunAt:  job.RunAt.Time(),
+		Every:  job.Every.Duration(),
+		Handle: job.Handle,
+		Bucket: int(job.RunAt) % numBuckets,
+	}
+}
+
+// Jobs returns a snapshot of all of the pending jobs. The jobs which are being
+// executed by a concurrent tick at the time of the call are not included.
+func (s *Scheduler) Jobs() []JobInfo {
+	s.drain()
+	jobs := make([]JobInfo, 0, 64)
+	for _, bucket := range s.wheels {
+		bucket.mu.Lock()
+		bucket.merge()
+		for i := range bucket.queue {
+			jobs = append(jobs, infoOf(&bucket.queue[i]))
+		}
+		bucket.mu.Unlock()
+	}
+	return jobs
+}
+
+// Recurring returns a snapshot of the pending recurring jobs, excluding the one-shot jobs. The
+// jobs which are being executed by a concurrent tick at the time of the call are not included.
+func (s *Scheduler) Recurring() []JobInfo {
+	s.drain()
+	jobs := make([]JobInfo, 0, 16)
+	for _, bucket := range s.wheels {
+		bucket.mu.Lock()
+		bucket.merge()
+		for i := range bucket.queue {
+			if bucket.queue[i].Every != 0 {
+				jobs = append(jobs, infoOf(&bucket.queue[i]))
+			}
+		}
+		bucket.mu.Unlock()
+	}
+	return jobs
+}
+
+// Occupancy returns a snapshot of the number of pending jobs in each bucket of the wheel,
+// indexed by the bucket. This can be used to visualize how the load is distributed across
+// the wheel. Each bucket is counted under its own lock, one after another. The
+// coarse-grained jobs are counted once they cascade into the wheel, see RunAfterCoarse.
+func (s *Scheduler) Occupancy() []int {
+	s.drain()
+	occupancy := make([]int, len(s.buckets))
+	for i, bucket := range s.buckets {
+		occupancy[i] = bucket.size()
+	}
+	return occupancy
+}
+
+// CancelWhere cancels all of the pending jobs that match the predicate and returns how
+// many jobs were cancelled. The jobs are removed from their buckets under lock, so this
+// is safe to call concurrently with a tick, or from within a task. The jobs which are
+// being executed at the time of the call are not affected.
+func (s *Scheduler) CancelWhere(pred func(JobInfo) bool) (count int) {
+	var completed []*Handle
+	s.drain()
+	for _, bucket := range s.wheels {
+		offset := 0
+		bucket.mu.Lock()
+		bucket.merge()
+		for i := range bucket.queue {
+			job := &bucket.queue[i]
+			if !pred(infoOf(job)) {
+				bucket.queue[offset] = bucket.queue[i]
+				offset++
+				continue
+			}
+
+			if job.Handle != nil {
+				job.Handle.Cancel()
+			}
+			if job.Every != 0 {
+				completed = append(completed, job.Handle)
+			}
+			count++
+		}
+		clear(bucket.queue[offset:]) // release the closures of the removed jobs
+		bucket.queue = bucket.queue[:offset]
+		bucket.mu.Unlock()
+	}
+
+	s.counters.pending.Add(-int64(count))
+
+	// The removed recurring jobs are never executed again, so they are complete
+	if complete := s.hooks.complete.Load(); complete != nil {
+		for _, h := range completed {
+			(*complete)(h)
+		}
+	}
+	return
+}
+
+// CancelBefore cancels all of the pending jobs which are due to run before 'when' and
+// returns how many jobs were cancelled, similarly to CancelWhere.
+func (s *Scheduler) CancelBefore(when time.Time) int {
+	return s.CancelWhere(func(job JobInfo) bool {
+		return job.RunAt.Before(when)
+	})
+}
+
+// CancelAfter cancels all of the pending jobs which are due to run after 'when' and
+// returns how many jobs were cancelled, similarly to CancelWhere.
+func (s *Scheduler) CancelAfter(when time.Time) int {
+	return s.CancelWhere(func(job JobInfo) bool {
+		return job.RunAt.After(when)
+	})
+}
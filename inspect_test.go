@@ -0,0 +1,124 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobs(t *testing.T) {
+	now := time.Unix(0, 0)
+	var count Counter
+
+	s := newScheduler(now)
+	s.RunAfter(count.Inc(), 50*time.Millisecond)
+	s.RunEvery(count.Inc(), time.Second)
+	h := s.Schedule(count.Inc(), now.Add(2*time.Second), 0)
+
+	jobs := s.Jobs()
+	assert.ElementsMatch(t, []JobInfo{
+		{RunAt: now.Add(50 * time.Millisecond), Bucket: 5},
+		{RunAt: now, Every: time.Second},
+		{RunAt: now.Add(2 * time.Second), Handle: h},
+	}, jobs)
+}
+
+func TestRecurring(t *testing.T) {
+	now := time.Unix(0, 0)
+	var count Counter
+
+	s := newScheduler(now)
+	s.RunAfter(count.Inc(), 50*time.Millisecond)
+	s.RunEvery(count.Inc(), time.Second)
+	h := s.Schedule(count.Inc(), now.Add(2*time.Second), 500*time.Millisecond)
+
+	assert.ElementsMatch(t, []JobInfo{
+		{RunAt: now, Every: time.Second},
+		{RunAt: now.Add(2 * time.Second), Every: 500 * time.Millisecond, Handle: h},
+	}, s.Recurring())
+}
+
+func TestOccupancy(t *testing.T) {
+	now := time.Unix(0, 0)
+	var count Counter
+
+	s := newScheduler(now, WithShards(2))
+	s.Run(count.Inc())
+	s.RunAfter(count.Inc(), 50*time.Millisecond)
+	s.RunAfter(count.Inc(), 1050*time.Millisecond)
+	s.RunEvery(count.Inc(), 100*time.Millisecond)
+
+	occupancy := s.Occupancy()
+	assert.Len(t, occupancy, numBuckets)
+	assert.Equal(t, 2, occupancy[0])
+	assert.Equal(t, 2, occupancy[5])
+
+	s.Tick()
+	assert.Equal(t, 0, s.Occupancy()[0])
+	assert.Equal(t, 1, s.Occupancy()[10])
+}
+
+func TestCancelWhere(t *testing.T) {
+	now := time.Unix(0, 0)
+	var count Counter
+
+	s := newScheduler(now)
+	s.RunAfter(count.Inc(), 50*time.Millisecond)
+	s.RunEvery(count.Inc(), 100*time.Millisecond)
+	s.RunEveryAfter(count.Inc(), 200*time.Millisecond, 10*time.Millisecond)
+	h := s.Schedule(count.Inc(), now.Add(20*time.Millisecond), time.Second)
+
+	// Cancel all recurring jobs
+	assert.Equal(t, 3, s.CancelWhere(func(job JobInfo) bool {
+		return job.Every != 0
+	}))
+
+	for i := 0; i < 100; i++ {
+		s.Tick()
+	}
+
+	assert.Equal(t, 1, count.Value())
+	assert.Equal(t, Cancelled, h.State())
+	assert.Empty(t, s.Jobs())
+}
+
+func TestCancelWhereFromTask(t *testing.T) {
+	now := time.Unix(0, 0)
+	var count Counter
+
+	s := newScheduler(now)
+	s.Run(func(now time.Time, elapsed time.Duration) bool {
+		s.CancelWhere(func(job JobInfo) bool {
+			return job.RunAt.After(now.Add(time.Second))
+		})
+		return true
+	})
+
+	s.RunAfter(count.Inc(), 500*time.Millisecond)
+	s.RunAfter(count.Inc(), 2*time.Second)
+	for i := 0; i < 300; i++ {
+		s.Tick()
+	}
+
+	assert.Equal(t, 1, count.Value())
+}
+
+func TestCancelBeforeAfter(t *testing.T) {
+	now := time.Unix(0, 0)
+	var count Counter
+
+	s := newScheduler(now)
+	s.RunAfter(count.Inc(), 50*time.Millisecond)
+	s.RunAfter(count.Inc(), 2*time.Second)
+	s.RunAfter(count.Inc(), 5*time.Second)
+	s.RunAfter(count.Inc(), 8*time.Second)
+
+	assert.Equal(t, 1, s.CancelBefore(now.Add(time.Second)))
+	assert.Equal(t, 1, s.CancelAfter(now.Add(5*time.Second)))
+	assert.Equal(t, 0, s.CancelBefore(now.Add(2*time.Second)))
+	assert.Len(t, s.Jobs(), 2)
+}
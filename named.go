@@ -0,0 +1,58 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"context"
+	"sync"
+)
+
+// timelines contains the named schedulers, created lazily
+var timelines struct {
+	sync.Mutex
+	named map[string]*running
+}
+
+// running represents a started scheduler along with its cancellation
+type running struct {
+	*Scheduler
+	cancel context.CancelFunc
+}
+
+// Named returns a started scheduler for the given name, creating it if necessary. This
+// allows different subsystems to have isolated clocks without passing instances around.
+func Named(name string) *Scheduler {
+	timelines.Lock()
+	defer timelines.Unlock()
+	if timelines.named == nil {
+		timelines.named = make(map[string]*running)
+	}
+
+	if s, ok := timelines.named[name]; ok {
+		return s.Scheduler
+	}
+
+	s := New()
+	cancel, _ := s.Start(context.Background())
+	timelines.named[name] = &running{
+		Scheduler: s,
+		cancel:    cancel,
+	}
+	return s
+}
+
+// Stop stops the internal clock of a named scheduler and removes it from the registry,
+// a subsequent call to Named will create a new scheduler. It returns whether a
+// scheduler with the given name was found.
+func Stop(name string) bool {
+	timelines.Lock()
+	s, ok := timelines.named[name]
+	delete(timelines.named, name)
+	timelines.Unlock()
+
+	if ok {
+		s.cancel()
+	}
+	return ok
+}
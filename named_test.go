@@ -0,0 +1,30 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamed(t *testing.T) {
+	a, b := Named("a"), Named("b")
+	assert.NotSame(t, a, b)
+	assert.Same(t, a, Named("a"))
+
+	var count Counter
+	a.Run(count.Inc())
+	assert.Eventually(t, func() bool {
+		return count.Value() == 1
+	}, time.Second, time.Millisecond)
+
+	// Stop the scheduler, a new one is created afterwards
+	assert.True(t, Stop("a"))
+	assert.False(t, Stop("a"))
+	assert.NotSame(t, a, Named("a"))
+	assert.True(t, Stop("a"))
+	assert.True(t, Stop("b"))
+}
@@ -0,0 +1,58 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"errors"
+	"time"
+)
+
+// RunOneOf schedules exactly one of the tasks to run after a 'delay'. The task is chosen
+// at random according to its weight when the job fires, rather than when it is scheduled, so
+// the selection can depend on the state at that time. The weights must be non-negative and
+// there must be as many weights as tasks, with at least one of them being positive.
+func (s *Scheduler) RunOneOf(tasks []Task, weights []float64, delay time.Duration) error {
+	if len(tasks) != len(weights) {
+		return errors.New("timeline: the number of tasks and weights must match")
+	}
+
+	total := 0.0
+	for _, w := range weights {
+		if w < 0 {
+			return errors.New("timeline: weights must be non-negative")
+		}
+		total += w
+	}
+
+	if total <= 0 {
+		return errors.New("timeline: at least one weight must be positive")
+	}
+
+	// Copy the tasks and weights so the caller can't change them until the job fires
+	tasks = append([]Task(nil), tasks...)
+	weights = append([]float64(nil), weights...)
+	s.RunAfter(func(now time.Time, elapsed time.Duration) bool {
+		return tasks[pick(&s.rand, weights, total)](now, elapsed)
+	}, delay)
+	return nil
+}
+
+// pick returns the index of a randomly chosen weight.
+func pick(rng *random, weights []float64, total float64) int {
+	r := rng.Float64() * total
+	for i, w := range weights {
+		if r < w {
+			return i
+		}
+		r -= w
+	}
+
+	// Guard against rounding errors, pick the last positive weight
+	for i := len(weights) - 1; i > 0; i-- {
+		if weights[i] > 0 {
+			return i
+		}
+	}
+	return 0
+}
@@ -0,0 +1,57 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunOneOf(t *testing.T) {
+	now := time.Unix(0, 0)
+	var a, b, c Counter
+
+	s := newScheduler(now)
+	for i := 0; i < 1000; i++ {
+		assert.NoError(t, s.RunOneOf([]Task{a.Inc(), b.Inc(), c.Inc()}, []float64{1, 3, 0}, 50*time.Millisecond))
+	}
+
+	s.Advance(40 * time.Millisecond)
+	assert.Equal(t, 0, a.Value()+b.Value()+c.Value())
+
+	// Exactly one task runs per job, according to the weights
+	s.Advance(20 * time.Millisecond)
+	assert.Equal(t, 1000, a.Value()+b.Value())
+	assert.Equal(t, 0, c.Value())
+	assert.InDelta(t, 750, b.Value(), 100)
+}
+
+func TestRunOneOfCopy(t *testing.T) {
+	var a, b Counter
+	s := newScheduler(time.Unix(0, 0))
+	tasks := []Task{a.Inc()}
+	assert.NoError(t, s.RunOneOf(tasks, []float64{1}, 0))
+
+	// Changing the tasks after scheduling does not affect the job
+	tasks[0] = b.Inc()
+	s.Advance(20 * time.Millisecond)
+	assert.Equal(t, 1, a.Value())
+	assert.Equal(t, 0, b.Value())
+}
+
+func TestRunOneOfInvalid(t *testing.T) {
+	s := New()
+	var count Counter
+	assert.Error(t, s.RunOneOf([]Task{count.Inc()}, []float64{1, 2}, 0))
+	assert.Error(t, s.RunOneOf([]Task{count.Inc()}, []float64{-1}, 0))
+	assert.Error(t, s.RunOneOf([]Task{count.Inc()}, []float64{0}, 0))
+	assert.Error(t, s.RunOneOf(nil, nil, 0))
+}
+
+func TestPick(t *testing.T) {
+	assert.Equal(t, 1, pick(new(random), []float64{0, 1, 0}, 1))
+	assert.Equal(t, 2, pick(new(random), []float64{0, 0, 1}, 1))
+}
@@ -0,0 +1,134 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import "time"
+
+// Option represents a configuration option of the scheduler.
+type Option func(*Scheduler)
+
+// shrink represents the shrinking policy of the bucket queues.
+type shrink struct {
+	ratio     float64 // the length to capacity ratio below which a queue is considered idle
+	rotations int     // the number of idle rotations before the queue is shrunk
+}
+
+// WithShrink configures how the bucket queues are shrunk after a spike. A queue is
+// shrunk by half once its length stays below 'ratio' of its capacity for a number
+// of consecutive 'rotations' of the wheel. Setting 'rotations' to 0 disables it.
+func WithShrink(ratio float64, rotations int) Option {
+	return func(s *Scheduler) {
+		s.shrink = shrink{
+			ratio:     ratio,
+			rotations: rotations,
+		}
+	}
+}
+
+// WithSmoothing enables the redistribution of recurring jobs across buckets. When a recurring
+// job is scheduled, its first run is nudged towards the least loaded bucket within 'tolerance'
+// of its nominal time. The job then keeps its exact interval, so the jobs sharing the same
+// interval remain spread out instead of clustering into the same buckets. Nudging on every
+// reschedule instead would make the jobs drift, since later buckets always look less loaded.
+func WithSmoothing(tolerance time.Duration) Option {
+	return func(s *Scheduler) {
+		s.smoothing = tick(durationOf(tolerance))
+	}
+}
+
+// WithBudget configures the time budget of the tasks scheduled with RunCtx, after which
+// their context expires. By default this is the resolution of the clock.
+func WithBudget(budget time.Duration) Option {
+	return func(s *Scheduler) {
+		s.budget = budget
+	}
+}
+
+// WithExecutor hands the due tasks of every tick over to the executor, as a single function,
+// instead of running them on the goroutine of the clock. This can be used to run all of the
+// tasks on a dedicated OS thread, such as the one owning a graphics or audio context. The
+// executor may run the function asynchronously, but the tick waits until it has run, and
+// the recurring tasks are rescheduled by that same function once they have returned. If a
+// task panics, the panic is recovered on the executor and raised again by the tick.
+func WithExecutor(exec func(func())) Option {
+	return func(s *Scheduler) {
+		s.executor = exec
+	}
+}
+
+// WithResync aligns the internal clock started with Start with the wall clock whenever it
+// drifts by more than 'threshold', as reported by Drift. When the clock fell behind, the
+// overdue tasks are promoted to the next tick, just like with Seek. Disabled by default.
+func WithResync(threshold time.Duration) Option {
+	return func(s *Scheduler) {
+		s.resync = threshold
+	}
+}
+
+// WithStartAlignment aligns the first tick of the internal clock started with Start to the
+// next multiple of 'alignment', for example to the next whole second, so that the ticks of
+// the schedulers on different hosts are roughly in phase. Start blocks until that boundary
+// is reached. By default, the clock is aligned to the resolution of the clock.
+func WithStartAlignment(alignment time.Duration) Option {
+	return func(s *Scheduler) {
+		if alignment < resolution {
+			alignment = resolution
+		}
+
+		s.alignment = alignment
+	}
+}
+
+// WithMaxJobs limits the number of pending jobs to 'n', protecting against a producer flooding
+// the scheduler. Once the limit is reached, the newly scheduled tasks are rejected and counted
+// in the Rejected field of Stats, while the pending jobs keep being executed and the recurring
+// ones keep being rescheduled. A handle returned by Schedule for a rejected task is cancelled.
+// By default, the number of pending jobs is unlimited.
+func WithMaxJobs(n int) Option {
+	return func(s *Scheduler) {
+		s.maxJobs = int64(n)
+	}
+}
+
+// WithStableOrder executes the jobs due on the same tick in the order in which they were
+// first scheduled, regardless of which bucket they were rescheduled from, so that replaying
+// the same sequence of calls produces identical results. Recurring jobs keep the position
+// of their first schedule. The tasks scheduled with Run no longer go through the lock-free
+// inbox, since their position must be known at the time they are scheduled.
+func WithStableOrder() Option {
+	return func(s *Scheduler) {
+		s.stable = true
+	}
+}
+
+// WithShards splits every bucket into a number of 'shards', each with its own lock, so that
+// goroutines scheduling concurrently into the same bucket don't contend on a single lock. The
+// shards are merged into the bucket when it is processed. Since the shards are chosen in a
+// round-robin fashion, the jobs due on the same tick are no longer executed in the order in
+// which they were scheduled. By default, buckets are not sharded.
+func WithShards(shards int) Option {
+	return func(s *Scheduler) {
+		if shards <= 1 {
+			return
+		}
+
+		for _, bucket := range s.buckets {
+			bucket.shards = make([]shard, shards)
+		}
+	}
+}
+
+// EveryOption represents an option of a recurring task scheduled with RunEvery.
+type EveryOption func(*recurrence)
+
+// recurrence represents the configuration of a recurring task.
+type recurrence struct {
+	immediately bool // Run the task on the next tick rather than the next boundary
+}
+
+// Immediately is an option of RunEvery which runs the task on the next tick, and then at
+// every interval from there, rather than starting at the next boundary tick.
+var Immediately EveryOption = func(r *recurrence) {
+	r.immediately = true
+}
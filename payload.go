@@ -0,0 +1,26 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"time"
+)
+
+// TaskData defines a scheduled function which receives the payload it was scheduled with,
+// so that the same function can be reused for different data without a closure.
+type TaskData = func(data any, now time.Time, elapsed time.Duration) bool
+
+// RunData schedules the function to run once after a 'delay', passing it the 'data'. The
+// payload is kept in the job itself rather than captured by a closure, so scheduling a
+// reused function with a pointer as its payload does not allocate. The return value of
+// the function is ignored, since the job runs only once.
+func (s *Scheduler) RunData(data any, fn TaskData, delay time.Duration) {
+	when := s.after(delay)
+	s.enqueueJob(job{
+		Call:  fn,
+		Data:  data,
+		RunAt: when,
+		Since: span(when - s.now()),
+	})
+}
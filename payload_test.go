@@ -0,0 +1,94 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+cpu: Intel(R) Xeon(R) Processor
+BenchmarkPayload/closure         	 4243442	       293.2 ns/op	      16 B/op	       1 allocs/op
+BenchmarkPayload/payload         	 4746292	       249.2 ns/op	       0 B/op	       0 allocs/op
+*/
+func BenchmarkPayload(b *testing.B) {
+	type entity struct{ hits int }
+	e := new(entity)
+
+	b.Run("closure", func(b *testing.B) {
+		s := New()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			s.RunAfter(func(time.Time, time.Duration) bool {
+				e.hits++
+				return false
+			}, 0)
+			s.Tick()
+		}
+	})
+
+	b.Run("payload", func(b *testing.B) {
+		s := New()
+		hit := func(data any, _ time.Time, _ time.Duration) bool {
+			data.(*entity).hits++
+			return false
+		}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			s.RunData(e, hit, 0)
+			s.Tick()
+		}
+	})
+}
+
+func TestRunData(t *testing.T) {
+	now := time.Unix(0, 0)
+	log := make(Log, 0, 8)
+
+	s := newScheduler(now)
+	write := func(data any, now time.Time, elapsed time.Duration) bool {
+		log = append(log, fmt.Sprintf("%v at %d", data, now.UnixMilli()))
+		return true
+	}
+
+	s.RunData("B", write, 50*time.Millisecond)
+	s.RunData("A", write, 0)
+	s.RunData(nil, write, 1500*time.Millisecond)
+	s.Advance(2 * time.Second)
+
+	// Every job runs once, with its own payload
+	assert.Equal(t, Log{
+		"A at 0",
+		"B at 50",
+		"<nil> at 1500",
+	}, log)
+}
+
+func TestRunDataSlowTask(t *testing.T) {
+	now := time.Unix(0, 0)
+	var slow Task
+	var got []any
+
+	s := newScheduler(now)
+	s.OnSlowTask(0, func(d time.Duration, task Task) {
+		slow = task
+	})
+
+	s.RunData(42, func(data any, now time.Time, elapsed time.Duration) bool {
+		got = append(got, data)
+		return false
+	}, 0)
+	s.Tick()
+
+	// The reported task carries the payload
+	slow(now, 0)
+	assert.Equal(t, []any{42, 42}, got)
+}
@@ -0,0 +1,141 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Factory reconstructs a task from its persisted arguments.
+type Factory = func(args []byte) Task
+
+// registry keeps track of the named task factories and the scheduled named jobs,
+// so that they can be persisted and restored.
+type registry struct {
+	mu        sync.Mutex
+	factories map[string]Factory
+	jobs      map[*record]struct{}
+}
+
+// record represents a persisted named job.
+type record struct {
+	Name  string        `json:"name"`
+	Args  []byte        `json:"args,omitempty"`
+	RunAt time.Time     `json:"runAt"`
+	Every time.Duration `json:"every,omitempty"`
+}
+
+// Register registers a named task factory which is used to reconstruct the tasks
+// scheduled with RunNamed when the jobs are restored with Load.
+func (s *Scheduler) Register(name string, factory Factory) {
+	s.named.mu.Lock()
+	defer s.named.mu.Unlock()
+	if s.named.factories == nil {
+		s.named.factories = make(map[string]Factory)
+	}
+
+	s.named.factories[name] = factory
+}
+
+// RunNamed schedules a task created by the named factory to run at a specific 'when'
+// time. Unlike other tasks, named tasks are persisted by Save and restored by Load. It
+// returns ErrRejected if the maximum number of pending jobs is reached, see WithMaxJobs.
+func (s *Scheduler) RunNamed(name string, args []byte, when time.Time) error {
+	return s.runNamed(&record{
+		Name:  name,
+		Args:  args,
+		RunAt: when,
+	})
+}
+
+// RunNamedEvery schedules a task created by the named factory to run at 'interval'
+// intervals, starting at 'startTime'. The job is persisted by Save and restored by Load.
+func (s *Scheduler) RunNamedEvery(name string, args []byte, interval time.Duration, startTime time.Time) error {
+	return s.runNamed(&record{
+		Name:  name,
+		Args:  args,
+		RunAt: startTime,
+		Every: interval,
+	})
+}
+
+// runNamed schedules a named job and keeps track of it until it completes.
+func (s *Scheduler) runNamed(r *record) error {
+	s.named.mu.Lock()
+	factory, ok := s.named.factories[r.Name]
+	if !ok {
+		s.named.mu.Unlock()
+		return fmt.Errorf("timeline: task factory '%s' is not registered", r.Name)
+	}
+
+	if s.named.jobs == nil {
+		s.named.jobs = make(map[*record]struct{})
+	}
+	s.named.jobs[r] = struct{}{}
+	s.named.mu.Unlock()
+
+	// Wrap the task so we can track the next execution time
+	task := factory(r.Args)
+	wrap := func(now time.Time, elapsed time.Duration) bool {
+		repeat := task(now, elapsed)
+		if repeat && r.Every > 0 {
+			s.named.mu.Lock()
+			r.RunAt = now.Add(r.Every)
+			s.named.mu.Unlock()
+		}
+		return repeat
+	}
+
+	// Restored jobs that are overdue are executed on the next tick
+	when := tickOf(r.RunAt)
+	if now := s.now(); when < now {
+		when = now
+	}
+
+	// Stop tracking the job once it is done or cancelled, however it was cancelled
+	handle := s.Schedule(wrap, when.Time(), r.Every)
+	handle.OnSettled(func(State) {
+		s.named.mu.Lock()
+		delete(s.named.jobs, r)
+		s.named.mu.Unlock()
+	})
+
+	if handle.State() == Cancelled {
+		return ErrRejected
+	}
+	return nil
+}
+
+// Save writes all of the pending named jobs into the writer. Only tasks scheduled
+// with RunNamed or RunNamedEvery are persisted, since closures can't be serialized.
+func (s *Scheduler) Save(dst io.Writer) error {
+	s.named.mu.Lock()
+	jobs := make([]record, 0, len(s.named.jobs))
+	for r := range s.named.jobs {
+		jobs = append(jobs, *r)
+	}
+	s.named.mu.Unlock()
+
+	return json.NewEncoder(dst).Encode(jobs)
+}
+
+// Load reads the named jobs from the reader and schedules them, reconstructing each
+// task using the factory registered under its name.
+func (s *Scheduler) Load(src io.Reader) error {
+	var jobs []record
+	if err := json.NewDecoder(src).Decode(&jobs); err != nil {
+		return err
+	}
+
+	for i := range jobs {
+		if err := s.runNamed(&jobs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveLoad(t *testing.T) {
+	now := time.Unix(0, 0)
+	log := make(Log, 0, 8)
+	factory := func(args []byte) Task {
+		return log.Log(string(args))
+	}
+
+	// Schedule a few named jobs and persist them
+	s1 := newScheduler(now)
+	s1.Register("log", factory)
+	assert.NoError(t, s1.RunNamed("log", []byte("A"), now.Add(50*time.Millisecond)))
+	assert.NoError(t, s1.RunNamed("log", []byte("B"), now.Add(1500*time.Millisecond)))
+	assert.NoError(t, s1.RunNamedEvery("log", []byte("C"), 500*time.Millisecond, now.Add(100*time.Millisecond)))
+	for i := 0; i < 20; i++ {
+		s1.Tick()
+	}
+
+	var buffer bytes.Buffer
+	assert.NoError(t, s1.Save(&buffer))
+	assert.Equal(t, Log{"A", "C"}, log)
+
+	// Restore them on a different scheduler
+	log = log[:0]
+	s2 := newScheduler(now.Add(200 * time.Millisecond))
+	s2.Register("log", factory)
+	assert.NoError(t, s2.Load(&buffer))
+	for i := 0; i < 140; i++ {
+		s2.Tick()
+	}
+
+	assert.Equal(t, Log{"C", "C", "B"}, log)
+}
+
+func TestRunNamedUnregistered(t *testing.T) {
+	s := New()
+	assert.Error(t, s.RunNamed("missing", nil, time.Now()))
+	assert.Error(t, s.Load(bytes.NewBufferString(`[{"name":"missing"}]`)))
+	assert.Error(t, s.Load(bytes.NewBufferString(`{`)))
+}
+
+func TestRunNamedRejected(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newScheduler(now, WithMaxJobs(1))
+	s.Register("noop", func([]byte) Task {
+		return func(time.Time, time.Duration) bool { return false }
+	})
+
+	// The rejected job is not persisted
+	assert.NoError(t, s.RunNamed("noop", []byte("A"), now.Add(time.Second)))
+	assert.ErrorIs(t, s.RunNamedEvery("noop", []byte("B"), time.Second, now), ErrRejected)
+
+	var buffer bytes.Buffer
+	assert.NoError(t, s.Save(&buffer))
+	assert.NotContains(t, buffer.String(), `"args":"Qg=="`)
+	assert.Contains(t, buffer.String(), `"args":"QQ=="`)
+}
+
+func TestSaveCancelled(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newScheduler(now)
+	s.Register("noop", func([]byte) Task {
+		return func(time.Time, time.Duration) bool { return true }
+	})
+
+	assert.NoError(t, s.RunNamed("noop", []byte("A"), now.Add(time.Second)))
+	assert.NoError(t, s.RunNamedEvery("noop", []byte("B"), time.Second, now))
+	assert.NoError(t, s.RunNamed("noop", []byte("C"), now.Add(time.Second)))
+	assert.Equal(t, 1, s.CancelBefore(now.Add(500*time.Millisecond)))
+	for _, job := range s.Jobs() {
+		if job.RunAt.Equal(now.Add(time.Second)) {
+			job.Handle.Cancel()
+			break
+		}
+	}
+
+	// The cancelled jobs are no longer persisted
+	var buffer bytes.Buffer
+	assert.NoError(t, s.Save(&buffer))
+	assert.Equal(t, 1, strings.Count(buffer.String(), `"name":"noop"`))
+}
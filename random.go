@@ -0,0 +1,56 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// random represents the source of randomness of a scheduler, used by all of its randomized
+// decisions. Without a seed, it falls back to the shared source of the math/rand package.
+type random struct {
+	mu    sync.Mutex
+	rng   *rand.Rand // seeded source, nil to use the shared one
+	seed  int64      // seed of the source
+	draws uint64     // number of numbers drawn from the seeded source
+}
+
+// Float64 returns a pseudo-random number in [0.0, 1.0)
+func (r *random) Float64() float64 {
+	if r.rng == nil {
+		return rand.Float64()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.draws++
+	return r.rng.Float64()
+}
+
+// copyTo seeds the other source so that it continues with the same sequence of numbers. Since
+// the state of a seeded source can't be copied, it is replayed from the seed.
+func (r *random) copyTo(other *random) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.rng == nil {
+		return
+	}
+
+	other.seed, other.draws = r.seed, r.draws
+	other.rng = rand.New(rand.NewSource(r.seed))
+	for i := uint64(0); i < r.draws; i++ {
+		other.rng.Float64()
+	}
+}
+
+// WithRand seeds the source of randomness of the scheduler, which is used for the jitter of
+// the backoff and for choosing the task of RunOneOf, so that a simulation can be replayed
+// with identical results. By default, the shared source of the math/rand package is used.
+func WithRand(seed int64) Option {
+	return func(s *Scheduler) {
+		s.rand.rng = rand.New(rand.NewSource(seed))
+		s.rand.seed = seed
+	}
+}
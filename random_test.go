@@ -0,0 +1,41 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRand(t *testing.T) {
+	replay := func(seed int64) []int {
+		var picks []int
+		s := New(WithRand(seed))
+		s.Seek(time.Unix(0, 0))
+		for i := 0; i < 100; i++ {
+			assert.NoError(t, s.RunOneOf([]Task{
+				func(time.Time, time.Duration) bool { picks = append(picks, 0); return true },
+				func(time.Time, time.Duration) bool { picks = append(picks, 1); return true },
+			}, []float64{1, 1}, 0))
+		}
+
+		s.Tick()
+		return picks
+	}
+
+	// The same seed replays the same choices
+	assert.Equal(t, replay(42), replay(42))
+	assert.NotEqual(t, replay(42), replay(43))
+}
+
+func TestRandShared(t *testing.T) {
+	var r random
+	for i := 0; i < 100; i++ {
+		v := r.Float64()
+		assert.GreaterOrEqual(t, v, 0.0)
+		assert.Less(t, v, 1.0)
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"time"
+)
+
+// RunSequence schedules the tasks to run one after another, starting on the next tick. Each
+// task is scheduled only once the previous one has returned, a 'gap' after the tick it ran on,
+// so that its side effects are complete before the next one starts. If a task returns false,
+// the remaining tasks of the sequence are not run.
+func (s *Scheduler) RunSequence(tasks []Task, gap time.Duration) {
+	if len(tasks) == 0 {
+		return
+	}
+
+	// Copy the tasks so the caller can't change them while the sequence runs
+	tasks = append([]Task(nil), tasks...)
+	if gap < resolution {
+		gap = resolution // the next step runs on the next tick at the earliest
+	}
+
+	s.RunDynamic(func(now time.Time, elapsed time.Duration) time.Duration {
+		task := tasks[0]
+		if tasks = tasks[1:]; !task(now, elapsed) || len(tasks) == 0 {
+			return 0
+		}
+		return gap
+	})
+}
@@ -0,0 +1,68 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunSequence(t *testing.T) {
+	now := time.Unix(0, 0)
+	log := make(Log, 0, 8)
+
+	s := newScheduler(now)
+	s.RunSequence([]Task{log.Log("A"), log.Log("B"), log.Log("C")}, 100*time.Millisecond)
+	s.Tick()
+	assert.Equal(t, Log{"A"}, log)
+
+	// Each step runs a gap after the previous one
+	s.Advance(90 * time.Millisecond)
+	assert.Equal(t, Log{"A"}, log)
+	s.Advance(10 * time.Millisecond)
+	assert.Equal(t, Log{"A", "B"}, log)
+	s.Advance(100 * time.Millisecond)
+	assert.Equal(t, Log{"A", "B", "C"}, log)
+
+	s.Advance(time.Second)
+	assert.Equal(t, Log{"A", "B", "C"}, log)
+}
+
+func TestRunSequenceAbort(t *testing.T) {
+	now := time.Unix(0, 0)
+	log := make(Log, 0, 8)
+
+	s := newScheduler(now)
+	s.RunSequence([]Task{log.Log("A"), func(time.Time, time.Duration) bool {
+		return false
+	}, log.Log("C")}, 0)
+	s.RunSequence(nil, 0)
+
+	s.Advance(time.Second)
+	assert.Equal(t, Log{"A"}, log)
+}
+
+func TestRunSequenceAtLimit(t *testing.T) {
+	now := time.Unix(0, 0)
+	log := make(Log, 0, 8)
+
+	// The limit is reached by the first step, the rest of the sequence still runs
+	s := newScheduler(now, WithMaxJobs(4))
+	s.RunSequence([]Task{fill(s), log.Log("B"), log.Log("C")}, 100*time.Millisecond)
+	s.Advance(time.Second)
+	assert.Equal(t, Log{"B", "C"}, log)
+}
+
+// fill schedules idle jobs until the maximum number of pending jobs is reached
+func fill(s *Scheduler) Task {
+	return func(time.Time, time.Duration) bool {
+		idle := func(time.Time, time.Duration) bool { return false }
+		for s.Schedule(idle, s.Now().Add(time.Hour), 0).State() == Pending {
+			// rejected once the limit is reached, see WithMaxJobs
+		}
+		return true
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"math"
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// Latency represents a summary of how late the tasks were executed, relative to the
+// time they were scheduled to run at. Percentiles are approximated by the upper bound
+// of the histogram bucket they fall into.
+type Latency struct {
+	Count uint64        // Number of executed tasks
+	Min   time.Duration // Minimum lateness
+	Max   time.Duration // Maximum lateness
+	Mean  time.Duration // Average lateness
+	P50   time.Duration // Median lateness
+	P90   time.Duration // 90th percentile of lateness
+	P99   time.Duration // 99th percentile of lateness
+}
+
+// histogram represents a fixed-bucket histogram of lateness, in ticks. The bucket 0
+// counts the tasks that were on time, and the bucket i counts the lateness within
+// [2^(i-1), 2^i) ticks.
+type histogram struct {
+	buckets [65]atomic.Uint64
+	count   atomic.Uint64
+	sum     atomic.Uint64
+	min     atomic.Uint64
+	max     atomic.Uint64
+}
+
+// sample represents the lateness samples collected during a single tick, so that
+// the histogram is updated once per tick rather than once per task.
+type sample struct {
+	buckets  [65]uint64
+	count    uint64
+	sum      uint64
+	min, max uint64
+}
+
+// Add adds the lateness of a task, in ticks
+func (s *sample) Add(late tick) {
+	v := uint64(late)
+	if s.count == 0 || v < s.min {
+		s.min = v
+	}
+	if v > s.max {
+		s.max = v
+	}
+
+	s.buckets[bits.Len64(v)]++
+	s.count++
+	s.sum += v
+}
+
+// record merges the samples of a tick into the histogram
+func (h *histogram) record(s *sample) {
+	if s.count == 0 {
+		return
+	}
+
+	for i, n := range s.buckets[:bits.Len64(s.max)+1] {
+		if n > 0 {
+			h.buckets[i].Add(n)
+		}
+	}
+
+	h.count.Add(s.count)
+	h.sum.Add(s.sum)
+	for v := h.min.Load(); s.min < v && !h.min.CompareAndSwap(v, s.min); v = h.min.Load() {
+	}
+	for v := h.max.Load(); s.max > v && !h.max.CompareAndSwap(v, s.max); v = h.max.Load() {
+	}
+}
+
+// LatencyStats returns a summary of how late the tasks were executed relative to the
+// time they were scheduled to run at. This can be used to determine whether the
+// scheduler is keeping up with the load.
+func (s *Scheduler) LatencyStats() Latency {
+	h := &s.latency
+	count := h.count.Load()
+	if count == 0 {
+		return Latency{}
+	}
+
+	return Latency{
+		Count: count,
+		Min:   span(h.min.Load()).Duration(),
+		Max:   span(h.max.Load()).Duration(),
+		Mean:  time.Duration(float64(h.sum.Load()) / float64(count) * float64(resolution)),
+		P50:   h.percentile(count, 0.50),
+		P90:   h.percentile(count, 0.90),
+		P99:   h.percentile(count, 0.99),
+	}
+}
+
+// percentile returns the upper bound of the bucket which contains the percentile
+func (h *histogram) percentile(count uint64, p float64) time.Duration {
+	rank := uint64(math.Ceil(p * float64(count)))
+	total := uint64(0)
+	for i := range h.buckets {
+		if total += h.buckets[i].Load(); total >= rank {
+			if i == 0 {
+				return 0
+			}
+			return time.Duration((uint64(1)<<i)-1) * resolution
+		}
+	}
+
+	return span(h.max.Load()).Duration()
+}
+
+// Reschedules represents the number of times the recurring tasks were rescheduled, split
+// by whether the next run stayed in the same bucket (fast path) or had to be moved into a
+// different bucket. A high rate of cross-bucket reschedules indicates intervals which do
+// not divide the window evenly.
+type Reschedules struct {
+	InBucketReschedules    uint64 // Number of reschedules within the same bucket
+	CrossBucketReschedules uint64 // Number of reschedules into a different bucket
+}
+
+// moves represents the counters of the rescheduled recurring tasks
+type moves struct {
+	inBucket    atomic.Uint64
+	crossBucket atomic.Uint64
+}
+
+// record adds the reschedules counted during a single tick
+func (m *moves) record(inBucket, crossBucket uint64) {
+	if inBucket > 0 {
+		m.inBucket.Add(inBucket)
+	}
+	if crossBucket > 0 {
+		m.crossBucket.Add(crossBucket)
+	}
+}
+
+// RescheduleStats returns the number of times the recurring tasks were rescheduled within
+// the same bucket and across the buckets. This can be used to tune the task intervals.
+func (s *Scheduler) RescheduleStats() Reschedules {
+	return Reschedules{
+		InBucketReschedules:    s.moves.inBucket.Load(),
+		CrossBucketReschedules: s.moves.crossBucket.Load(),
+	}
+}
+
+// Stats represents a snapshot of the internal counters of the scheduler. The counters
+// are read one after another, so the snapshot is not taken atomically as a whole.
+type Stats struct {
+	Scheduled   uint64      // Number of tasks scheduled so far
+	Executed    uint64      // Number of tasks executed so far
+	Rejected    uint64      // Number of tasks rejected because of WithMaxJobs
+	Pending     int         // Number of jobs currently pending
+	Recurring   int         // Number of recurring jobs currently pending
+	Reschedules Reschedules // Number of reschedules of the recurring tasks
+	Latency     Latency     // Summary of the lateness of the executed tasks
+}
+
+// counters represents the counters of the scheduled and executed tasks
+type counters struct {
+	scheduled atomic.Uint64
+	executed  atomic.Uint64
+	rejected  atomic.Uint64
+	pending   atomic.Int64 // live number of pending jobs
+}
+
+// Stats returns a snapshot of the internal counters of the scheduler, along with the
+// number of pending jobs and the summary of their lateness.
+func (s *Scheduler) Stats() Stats {
+	stats := Stats{
+		Scheduled:   s.counters.scheduled.Load(),
+		Executed:    s.counters.executed.Load(),
+		Rejected:    s.counters.rejected.Load(),
+		Reschedules: s.RescheduleStats(),
+		Latency:     s.LatencyStats(),
+	}
+
+	s.drain()
+	for _, bucket := range s.wheels {
+		bucket.mu.Lock()
+		bucket.merge()
+		stats.Pending += len(bucket.queue)
+		for i := range bucket.queue {
+			if bucket.queue[i].Every != 0 {
+				stats.Recurring++
+			}
+		}
+		bucket.mu.Unlock()
+	}
+	return stats
+}
@@ -0,0 +1,128 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatencyStats(t *testing.T) {
+	now := time.Unix(10, 0)
+	var count Counter
+
+	s := newScheduler(now)
+	assert.Equal(t, Latency{}, s.LatencyStats())
+
+	// 98 tasks on time, 2 tasks late
+	for i := 0; i < 98; i++ {
+		s.Run(count.Inc())
+	}
+	s.RunAt(count.Inc(), now.Add(-1*time.Second))
+	s.RunAt(count.Inc(), now.Add(-2*time.Second))
+	s.Tick()
+
+	stats := s.LatencyStats()
+	assert.Equal(t, uint64(100), stats.Count)
+	assert.Equal(t, time.Duration(0), stats.Min)
+	assert.Equal(t, 2*time.Second, stats.Max)
+	assert.Equal(t, 30*time.Millisecond, stats.Mean)
+	assert.Equal(t, time.Duration(0), stats.P50)
+	assert.Equal(t, time.Duration(0), stats.P90)
+	assert.Equal(t, 1270*time.Millisecond, stats.P99)
+}
+
+func TestLatencyPercentile(t *testing.T) {
+	var h histogram
+	assert.Equal(t, time.Duration(0), h.percentile(1, 0.5))
+
+	var s sample
+	for i := 1; i <= 100; i++ {
+		s.Add(tick(i))
+	}
+
+	h.min.Store(1 << 63)
+	h.record(&s)
+	assert.Equal(t, uint64(100), h.count.Load())
+	assert.Equal(t, uint64(1), h.min.Load())
+	assert.Equal(t, uint64(100), h.max.Load())
+	assert.Equal(t, 630*time.Millisecond, h.percentile(100, 0.50))
+	assert.Equal(t, 1270*time.Millisecond, h.percentile(100, 0.99))
+}
+
+func TestRescheduleStats(t *testing.T) {
+	now := time.Unix(10, 0)
+	var count Counter
+
+	s := newScheduler(now)
+	assert.Equal(t, Reschedules{}, s.RescheduleStats())
+
+	// Intervals matching the window stay in-bucket, others move across the buckets
+	s.RunEvery(count.Inc(), time.Second)
+	s.RunEvery(count.Inc(), 100*time.Millisecond)
+	s.Advance(3 * time.Second)
+
+	stats := s.RescheduleStats()
+	assert.Equal(t, uint64(3), stats.InBucketReschedules)
+	assert.Equal(t, uint64(30), stats.CrossBucketReschedules)
+}
+
+func TestStats(t *testing.T) {
+	now := time.Unix(10, 0)
+	var count Counter
+
+	s := newScheduler(now)
+	assert.Equal(t, uint64(0), s.Stats().Scheduled)
+
+	s.Run(count.Inc())
+	s.RunAfter(count.Inc(), 50*time.Millisecond)
+	s.RunAfter(count.Inc(), 5*time.Second)
+	s.RunEvery(count.Inc(), 100*time.Millisecond)
+
+	stats := s.Stats()
+	assert.Equal(t, uint64(4), stats.Scheduled)
+	assert.Equal(t, uint64(0), stats.Executed)
+	assert.Equal(t, 4, stats.Pending)
+	assert.Equal(t, 1, stats.Recurring)
+
+	s.Advance(time.Second)
+	stats = s.Stats()
+	assert.Equal(t, uint64(4), stats.Scheduled)
+	assert.Equal(t, uint64(12), stats.Executed)
+	assert.Equal(t, 2, stats.Pending)
+	assert.Equal(t, 1, stats.Recurring)
+	assert.Equal(t, uint64(10), stats.Reschedules.CrossBucketReschedules)
+	assert.Equal(t, uint64(12), stats.Latency.Count)
+}
+
+func TestMaxJobs(t *testing.T) {
+	now := time.Unix(0, 0)
+	var count Counter
+
+	s := newScheduler(now, WithMaxJobs(3))
+	s.RunEvery(count.Inc(), 100*time.Millisecond)
+	s.Run(count.Inc())
+	s.RunAfter(count.Inc(), 50*time.Millisecond)
+	s.Run(count.Inc())
+	h := s.Schedule(count.Inc(), now, 0)
+	assert.Equal(t, Cancelled, h.State())
+	assert.Equal(t, uint64(2), s.Stats().Rejected)
+	assert.Equal(t, int64(3), s.counters.pending.Load())
+
+	// The pending jobs keep being served, and make room once done
+	s.Advance(250 * time.Millisecond)
+	assert.Equal(t, 5, count.Value())
+	assert.Equal(t, int64(1), s.counters.pending.Load())
+
+	s.RunAfter(count.Inc(), 10*time.Millisecond)
+	s.RunAfter(count.Inc(), 10*time.Millisecond)
+	s.RunAfter(count.Inc(), 10*time.Millisecond)
+	assert.Equal(t, 2, s.CancelWhere(func(job JobInfo) bool {
+		return job.Every == 0
+	}))
+	assert.Equal(t, uint64(3), s.Stats().Rejected)
+	assert.Equal(t, s.Stats().Pending, int(s.counters.pending.Load()))
+}
@@ -0,0 +1,97 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"sync"
+	"time"
+)
+
+// tags represents the index of the tagged jobs, keyed by their tag
+type tags struct {
+	mu    sync.Mutex
+	index map[string]map[*Handle]struct{}
+}
+
+// add adds the handle of a job to the tag
+func (t *tags) add(tag string, h *Handle) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.index == nil {
+		t.index = make(map[string]map[*Handle]struct{})
+	}
+
+	set, ok := t.index[tag]
+	if !ok {
+		set = make(map[*Handle]struct{})
+		t.index[tag] = set
+	}
+	set[h] = struct{}{}
+}
+
+// remove removes the handle of a job from the tag
+func (t *tags) remove(tag string, h *Handle) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if set, ok := t.index[tag]; ok {
+		delete(set, h)
+		if len(set) == 0 {
+			delete(t.index, tag)
+		}
+	}
+}
+
+// RunAfterTagged schedules a task to run after a 'delay', similarly to RunAfter, and tags the
+// job so that all of the jobs with the same tag can be cancelled at once with CancelByTag.
+func (s *Scheduler) RunAfterTagged(task Task, delay time.Duration, tag string) *Handle {
+	handle := new(Handle)
+	handle.state.Store(uint32(Pending))
+	handle.OnSettled(func(State) {
+		s.tags.remove(tag, handle) // however it was cancelled
+	})
+	s.tags.add(tag, handle)
+
+	when := s.after(delay)
+	if !s.enqueueJob(job{
+		Task: func(now time.Time, elapsed time.Duration) bool {
+			s.tags.remove(tag, handle)
+			task(now, elapsed)
+			return false
+		},
+		RunAt:  when,
+		Since:  span(when - s.now()),
+		Handle: handle,
+	}) {
+		handle.Cancel() // rejected, see WithMaxJobs
+	}
+	return handle
+}
+
+// Tagged returns the handles of the jobs with the given tag which did not run yet. The jobs
+// are no longer tagged once they have run or have been cancelled, however they were cancelled.
+func (s *Scheduler) Tagged(tag string) []*Handle {
+	s.tags.mu.Lock()
+	defer s.tags.mu.Unlock()
+	handles := make([]*Handle, 0, len(s.tags.index[tag]))
+	for h := range s.tags.index[tag] {
+		handles = append(handles, h)
+	}
+	return handles
+}
+
+// CancelByTag cancels all of the jobs with the given tag and returns how many jobs were
+// cancelled. This only visits the jobs with the tag, regardless of how many jobs are pending.
+func (s *Scheduler) CancelByTag(tag string) (count int) {
+	s.tags.mu.Lock()
+	set := s.tags.index[tag]
+	delete(s.tags.index, tag)
+	s.tags.mu.Unlock()
+
+	for h := range set {
+		if h.Cancel() {
+			count++
+		}
+	}
+	return
+}
@@ -0,0 +1,62 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCancelByTag(t *testing.T) {
+	var count Counter
+	s := newScheduler(time.Unix(0, 0))
+
+	a := s.RunAfterTagged(count.Inc(), 50*time.Millisecond, "screen")
+	s.RunAfterTagged(count.Inc(), 100*time.Millisecond, "screen")
+	s.RunAfterTagged(count.Inc(), 200*time.Millisecond, "screen")
+	other := s.RunAfterTagged(count.Inc(), 200*time.Millisecond, "other")
+	assert.Len(t, s.Tagged("screen"), 3)
+
+	// The executed jobs are no longer tagged
+	s.Advance(60 * time.Millisecond)
+	assert.Equal(t, 1, count.Value())
+	assert.Equal(t, Done, a.State())
+	assert.Len(t, s.Tagged("screen"), 2)
+
+	// Cancel the remaining jobs of the tag
+	assert.Equal(t, 2, s.CancelByTag("screen"))
+	assert.Equal(t, 0, s.CancelByTag("screen"))
+	assert.Empty(t, s.Tagged("screen"))
+
+	s.Advance(time.Second)
+	assert.Equal(t, 2, count.Value())
+	assert.Equal(t, Done, other.State())
+	assert.Empty(t, s.Tagged("other"))
+}
+
+func TestTaggedCancelled(t *testing.T) {
+	s := newScheduler(time.Unix(0, 0))
+	a := s.RunAfterTagged(func(time.Time, time.Duration) bool { return false }, 50*time.Millisecond, "screen")
+	b := s.RunAfterTagged(func(time.Time, time.Duration) bool { return false }, 50*time.Millisecond, "screen")
+	s.RunAfterTagged(func(time.Time, time.Duration) bool { return false }, 50*time.Millisecond, "screen")
+	assert.Len(t, s.Tagged("screen"), 3)
+
+	// The jobs cancelled without their tag are no longer tagged
+	assert.True(t, a.Cancel())
+	assert.True(t, b.Stop())
+	assert.Len(t, s.Tagged("screen"), 1)
+	s.CancelWhere(func(JobInfo) bool { return true })
+	assert.Empty(t, s.Tagged("screen"))
+	assert.Equal(t, 0, s.CancelByTag("screen"))
+}
+
+func TestTaggedRejected(t *testing.T) {
+	s := newScheduler(time.Unix(0, 0), WithMaxJobs(1))
+	s.RunAfterTagged(func(time.Time, time.Duration) bool { return false }, 50*time.Millisecond, "screen")
+	h := s.RunAfterTagged(func(time.Time, time.Duration) bool { return false }, 50*time.Millisecond, "screen")
+	assert.Equal(t, Cancelled, h.State())
+	assert.Len(t, s.Tagged("screen"), 1)
+}
@@ -0,0 +1,70 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"sync"
+	"time"
+)
+
+// Ticker represents a ticker driven by the scheduler's clock, mirroring time.Ticker. It
+// delivers the ticks on its channel and, just like time.Ticker, drops the ticks if the
+// consumer is not keeping up.
+type Ticker struct {
+	C      <-chan time.Time // The channel on which the ticks are delivered
+	c      chan time.Time
+	mu     sync.Mutex
+	owner  *Scheduler
+	handle *Handle
+}
+
+// NewTicker returns a new ticker which delivers the time on its channel at 'interval'
+// intervals, starting after the first interval. Intervals shorter than the resolution of
+// the clock are rounded up. It panics if the interval is not positive.
+func (s *Scheduler) NewTicker(interval time.Duration) *Ticker {
+	if interval <= 0 {
+		panic("timeline: non-positive interval for NewTicker")
+	}
+
+	c := make(chan time.Time, 1)
+	t := &Ticker{C: c, c: c, owner: s}
+	t.Reset(interval)
+	return t
+}
+
+// Stop turns off the ticker, after which no more ticks are delivered. Stop does not
+// close the channel, so that a concurrent receive does not observe a spurious tick.
+func (t *Ticker) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.handle.Cancel()
+}
+
+// Reset stops the ticker and reschedules it on the scheduler's clock, so that the next
+// tick is delivered after the new 'interval'. It panics if the interval is not positive.
+func (t *Ticker) Reset(interval time.Duration) {
+	if interval <= 0 {
+		panic("timeline: non-positive interval for Ticker.Reset")
+	}
+	if interval < resolution {
+		interval = resolution
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.handle != nil {
+		t.handle.Cancel()
+	}
+
+	t.handle = t.owner.Schedule(t.tick, t.owner.after(interval).Time(), interval)
+}
+
+// tick delivers the tick, dropping it if the consumer is lagging behind
+func (t *Ticker) tick(now time.Time, _ time.Duration) bool {
+	select {
+	case t.c <- now:
+	default:
+	}
+	return true
+}
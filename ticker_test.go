@@ -0,0 +1,48 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package timeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTicker(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newScheduler(now)
+	ticker := s.NewTicker(100 * time.Millisecond)
+
+	s.Advance(50 * time.Millisecond)
+	assert.Empty(t, ticker.C)
+
+	s.Advance(60 * time.Millisecond)
+	assert.Equal(t, now.Add(100*time.Millisecond), <-ticker.C)
+
+	// Slow consumer, the ticks are dropped
+	s.Advance(300 * time.Millisecond)
+	assert.Equal(t, now.Add(200*time.Millisecond), <-ticker.C)
+	assert.Empty(t, ticker.C)
+
+	// Reset with a different interval
+	ticker.Reset(20 * time.Millisecond)
+	s.Advance(30 * time.Millisecond)
+	assert.Equal(t, now.Add(430*time.Millisecond), <-ticker.C)
+
+	// No more ticks once stopped
+	ticker.Stop()
+	s.Advance(time.Second)
+	assert.Empty(t, ticker.C)
+}
+
+func TestTickerInvalid(t *testing.T) {
+	s := New()
+	assert.Panics(t, func() {
+		s.NewTicker(0)
+	})
+	assert.Panics(t, func() {
+		s.NewTicker(time.Second).Reset(-1)
+	})
+}
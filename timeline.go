@@ -5,14 +5,19 @@ package timeline
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
 const (
-	resolution = 10 * time.Millisecond
-	numBuckets = int(1 * time.Second / resolution)
+	resolution  = 10 * time.Millisecond
+	numBuckets  = int(1 * time.Second / resolution)
+	minCapacity = 64 // initial capacity of a bucket
 )
 
 // Task defines a scheduled function. 'now' is the execution time, and 'elapsed'
@@ -25,42 +30,232 @@ type Task = func(now time.Time, elapsed time.Duration) bool
 // job represents a scheduled task.
 type job struct {
 	Task
-	RunAt tick // When the task should run
-	Since span // Elapsed ticks between scheduled time and starting time
-	Every span // (optional) In ticks, how often the task should run (0 = once)
+	RunAt  tick     // When the task should run
+	Since  span     // Elapsed ticks between scheduled time and starting time
+	Every  span     // (optional) In ticks, how often the task should run (0 = once)
+	Handle *Handle  // (optional) The handle to track the state of the job
+	Seq    uint64   // (optional) Priority and insertion sequence, used to order the jobs due on the same tick
+	Call   TaskData // (optional) Runs in place of the task, with the payload of the job
+	Data   any      // (optional) The payload passed to the function, see RunData
+	Next   Dynamic  // (optional) Runs in place of the task and returns when to run next, see RunDynamic
 }
 
-// bucket represents a bucket for a particular window of the second.
-// TODO: this could be optimized with double buffering to reduce locking.
+// Dynamic defines a scheduled function which decides on its own when it should run next. It
+// returns the delay until its next execution, or zero to stop.
+type Dynamic = func(now time.Time, elapsed time.Duration) time.Duration
+
+// run executes the function of the job, which is either its task, the function it was scheduled
+// with along with its payload, or its dynamic function. The interval of a dynamic job is set to
+// the delay it returned, so that it is rescheduled by the scheduler which is executing it.
+func (j *job) run(now time.Time, elapsed time.Duration) bool {
+	switch {
+	case j.Call != nil:
+		return j.Call(j.Data, now, elapsed)
+	case j.Next != nil:
+		delay := j.Next(now, elapsed)
+		if delay <= 0 {
+			j.Every = 0
+			return false
+		}
+
+		// Delays below the resolution are clamped to a single tick
+		if j.Every = durationOf(delay); j.Every == 0 {
+			j.Every = 1
+		}
+		return true
+	default:
+		return j.Task(now, elapsed)
+	}
+}
+
+// task returns the task of the job, binding the payload or the dynamic function to it if the
+// job has no task of its own.
+func (j *job) task() Task {
+	if j.Task != nil {
+		return j.Task
+	}
+
+	bound := *j
+	return bound.run
+}
+
+const (
+	priorityShift = 56                   // The priority of a job is kept in the upper bits of its sequence
+	sequenceMask  = 1<<priorityShift - 1 // Mask of the insertion sequence of a job
+)
+
+// bucket represents a bucket for a particular window of the second. The due jobs are
+// moved into a spare buffer, so that tasks can be scheduled into the bucket while it is processed.
 type bucket struct {
+	mu     sync.Mutex
+	queue  []job
+	spare  []job         // spare buffer containing the due jobs while the bucket is processed
+	idle   int           // number of rotations the queue stayed well below its capacity
+	peak   int           // length of the queue before the due jobs were last collected
+	shards []shard       // optional shards receiving the scheduled jobs, see WithShards
+	cursor atomic.Uint32 // round-robin cursor of the shards
+}
+
+// shard represents a sub-queue of a bucket, so that concurrent appends are spread
+// across multiple locks. The jobs are merged into the queue of the bucket on demand.
+type shard struct {
 	mu    sync.Mutex
 	queue []job
+	_     [32]byte // padding to avoid false sharing between the shards
 }
 
 // Scheduler manages and executes scheduled tasks.
 type Scheduler struct {
-	next    atomic.Int64 // next tick
-	buckets []*bucket
+	next      atomic.Int64 // next tick
+	buckets   []*bucket
+	coarse    []*bucket     // second-tier wheel of the coarse-grained jobs, see RunAfterCoarse
+	wheels    []*bucket     // the buckets of both wheels
+	named     registry      // named jobs for persistence
+	tags      tags          // index of the tagged jobs
+	shrink    shrink        // shrinking policy of the buckets
+	hooks     hooks         // optional tick hooks
+	smoothing tick          // tolerance in ticks for smoothing recurring jobs across buckets
+	budget    time.Duration // time budget of a task with a context
+	alignment time.Duration // boundary the first tick of the internal clock is aligned to
+	maxJobs   int64         // maximum number of pending jobs, 0 if unlimited
+	resync    time.Duration // drift beyond which the clock is aligned with the wall clock, 0 if disabled
+	executor  func(func())  // optional executor running the due tasks of every tick, see WithExecutor
+	latency   histogram     // histogram of the lateness of the tasks
+	moves     moves         // counters of the rescheduled recurring tasks
+	counters  counters      // counters of the scheduled and executed tasks
+	clock     clock         // state of the internal clock
+	inbox     inbox         // lock-free queue of the tasks scheduled for the next tick
+	stable    bool          // whether the jobs due on the same tick are ordered by insertion
+	seq       atomic.Uint64 // last insertion sequence of the jobs
+	carry     atomic.Int64  // remainder of the simulation steps, below the resolution
+	lastTick  atomic.Int64  // wall-clock time of the last tick, in unix nanoseconds
+	onFire    observer      // optional observer of the executed jobs, for testing the invariants
+	rand      random        // source of randomness of the randomized decisions
+}
+
+// anchor represents the time the clock was set to, along with the instant it was set at. The
+// instant carries the monotonic clock reading, so that the time elapsed since is not affected
+// by the adjustments of the wall clock.
+type anchor struct {
+	when time.Time // The time of the scheduler's clock
+	at   time.Time // The instant of the wall clock
+}
+
+// observer observes the execution of a job which was due at 'due', on the tick 'now'.
+type observer func(now, due tick)
+
+// clock represents the state of the internal clock.
+type clock struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc     // cancels the running clock, nil if stopped
+	anchor atomic.Pointer[anchor] // the time the clock was last set to with Seek
+	wall   func() time.Time       // source of the wall clock, replaced when testing
 }
 
 // New initializes and returns a new Scheduler.
-func New() *Scheduler {
+func New(options ...Option) *Scheduler {
 	s := &Scheduler{
-		buckets: make([]*bucket, numBuckets),
+		wheels:    make([]*bucket, 2*numBuckets),
+		budget:    resolution,
+		alignment: resolution,
+		shrink: shrink{
+			ratio:     0.25,
+			rotations: 10,
+		},
 	}
 
-	for i := 0; i < numBuckets; i++ {
-		s.buckets[i] = &bucket{
-			queue: make([]job, 0, 64),
+	// The buckets of the coarse wheel are allocated on demand, since most jobs never use it
+	for i := range s.wheels {
+		s.wheels[i] = new(bucket)
+		if i < numBuckets {
+			s.wheels[i].queue = make([]job, 0, minCapacity)
 		}
 	}
 
+	s.buckets = s.wheels[:numBuckets:numBuckets]
+	s.coarse = s.wheels[numBuckets:]
+
+	for _, opt := range options {
+		opt(s)
+	}
+
+	s.clock.wall = time.Now
+	s.inbox.init()
+	s.latency.min.Store(math.MaxUint64)
 	return s
 }
 
-// Run schedules a task for the next tick.
+// Clone returns an independent copy of the scheduler, containing a copy of all of the
+// pending jobs, the current tick and the configuration, so that it can be advanced without
+// affecting the original one. The cloned jobs are tracked by their own handles, in the same
+// state as the original ones. Tasks themselves are shared between both schedulers, so it is
+// the caller's responsibility to deal with any external mutable state the cloned tasks may
+// observe. Tick hooks, named and tagged jobs are not copied.
+func (s *Scheduler) Clone() *Scheduler {
+	clone := New(WithShards(len(s.buckets[0].shards)), func(c *Scheduler) {
+		c.shrink = s.shrink
+		c.stable = s.stable
+		c.smoothing = s.smoothing
+		c.budget = s.budget
+		c.alignment = s.alignment
+		c.maxJobs = s.maxJobs
+		c.resync = s.resync
+		c.executor = s.executor
+		s.rand.copyTo(&c.rand)
+	})
+
+	clone.next.Store(s.next.Load())
+	clone.seq.Store(s.seq.Load())
+	handles := make(map[*Handle]*Handle)
+	s.drain()
+	for i, bucket := range s.wheels {
+		bucket.mu.Lock()
+		bucket.merge()
+		queue := append(clone.wheels[i].queue, bucket.queue...)
+		for j := range queue {
+			queue[j].Handle = cloneHandle(handles, queue[j].Handle)
+		}
+		clone.wheels[i].queue = queue
+		clone.counters.pending.Add(int64(len(bucket.queue)))
+		bucket.mu.Unlock()
+	}
+
+	return clone
+}
+
+// cloneHandle returns a copy of the handle in the same state, so that the cloned job is
+// tracked independently of the original one. A running job is pending in the clone.
+func cloneHandle(handles map[*Handle]*Handle, h *Handle) *Handle {
+	if h == nil {
+		return nil
+	}
+
+	if clone, ok := handles[h]; ok {
+		return clone
+	}
+
+	state := h.State()
+	if state == Running {
+		state = Pending
+	}
+
+	clone := new(Handle)
+	clone.state.Store(uint32(state))
+	handles[h] = clone
+	return clone
+}
+
+// Run schedules a task for the next tick. The tasks are enqueued without taking a lock
+// and are executed in the order in which they were scheduled, including with respect to
+// the tasks scheduled for the next tick with RunAt, RunAfter or Schedule, unless the
+// buckets are sharded with WithShards.
 func (s *Scheduler) Run(task Task) {
-	s.schedule(task, s.now(), 0)
+	if s.stable {
+		s.schedule(task, s.now(), 0) // the insertion order must be known when scheduled
+		return
+	}
+
+	s.enqueue(task)
 }
 
 // RunAt schedules a task for a specific 'at' time.
@@ -68,19 +263,106 @@ func (s *Scheduler) RunAt(task Task, at time.Time) {
 	s.schedule(task, tickOf(at), 0)
 }
 
+// RunAtPriority schedules a task for a specific 'at' time, similarly to RunAt, but the task is
+// executed before the tasks with a lower priority which are due on the same tick. The tasks
+// scheduled without a priority have the lowest priority of 0.
+func (s *Scheduler) RunAtPriority(task Task, at time.Time, priority uint8) {
+	s.enqueueJob(job{
+		Task:  task,
+		RunAt: tickOf(at),
+		Since: span(tickOf(at) - s.now()),
+		Seq:   uint64(priority) << priorityShift,
+	})
+}
+
+// RunOnceAt schedules a task to run exactly once at a specific 'when' time. If 'when' is
+// already in the past, or if the clock is moved past it with Seek, the task runs on the
+// next tick instead of being delayed until the wheel comes back to its bucket.
+func (s *Scheduler) RunOnceAt(task Task, when time.Time) {
+	at := tickOf(when)
+	if now := s.now(); at < now {
+		at = now
+	}
+
+	s.schedule(task, at, 0)
+}
+
 // RunAfter schedules a task to run after a 'delay'.
 func (s *Scheduler) RunAfter(task Task, delay time.Duration) {
 	s.schedule(task, s.after(delay), 0)
 }
 
+// RunInTicks schedules a task to run exactly 'n' ticks after the next tick, so that a zero
+// runs it on the next tick, just like Run. Unlike RunAfter, which truncates the delay down to
+// the resolution of the clock (a delay of 25ms is 2 ticks), the offset is given in ticks and
+// is not subject to any rounding, which suits the frame-based loops. A negative 'n' is zero.
+func (s *Scheduler) RunInTicks(task Task, n int) {
+	if n < 0 {
+		n = 0
+	}
+
+	s.schedule(task, s.now()+tick(n), 0)
+}
+
+// RunAtNextBoundary schedules a task to run once at the next multiple of 'boundary' on the
+// scheduler's clock, for example at the next whole minute. If the clock is exactly on a
+// boundary, the task runs on the current tick when 'inclusive' is set, or at the following
+// boundary otherwise. Boundaries shorter than the resolution of the clock are rounded up.
+func (s *Scheduler) RunAtNextBoundary(task Task, boundary time.Duration, inclusive bool) {
+	if boundary < resolution {
+		boundary = resolution
+	}
+
+	at := s.alignedAt(boundary)
+	if at == s.now() && !inclusive {
+		at += tick(durationOf(boundary))
+	}
+
+	s.schedule(task, at, 0)
+}
+
 // RunEvery schedules a task to run at 'interval' intervals, starting at the next boundary tick.
-func (s *Scheduler) RunEvery(task Task, interval time.Duration) {
-	s.schedule(task, s.alignedAt(interval), durationOf(interval))
+// If the current tick is already on a boundary, the task first runs on the current tick. The
+// previous boundary is considered as the last run, so the elapsed time of every execution,
+// including the first one, is the interval. With the Immediately option, the task first runs
+// on the next tick instead, and the following runs are spaced by the interval from there.
+func (s *Scheduler) RunEvery(task Task, interval time.Duration, options ...EveryOption) {
+	var r recurrence
+	for _, opt := range options {
+		opt(&r)
+	}
+
+	at, every := s.alignedAt(interval), durationOf(interval)
+	switch {
+	case r.immediately:
+		at = s.now()
+	case s.smoothing > 0:
+		at = s.leastLoaded(at)
+	}
+
+	s.enqueueJob(job{
+		Task:  task,
+		RunAt: at,
+		Since: every,
+		Every: every,
+	})
 }
 
-// RunEveryAt schedules a task to run at 'interval' intervals, starting at 'startTime'.
+// RunEveryAt schedules a task to run at 'interval' intervals, starting at 'startTime'. If
+// 'startTime' is in the past, the task first runs at the next occurrence at or after the
+// current tick, keeping the phase of 'startTime' rather than catching up on the missed runs.
 func (s *Scheduler) RunEveryAt(task Task, interval time.Duration, startTime time.Time) {
-	s.schedule(task, tickOf(startTime), durationOf(interval))
+	at, every := tickOf(startTime), durationOf(interval)
+	if now := s.now(); at < now {
+		switch {
+		case every == 0:
+			at = now
+		default:
+			at += (now - at + tick(every) - 1) / tick(every) * tick(every)
+		}
+	}
+
+	s.schedule(task, at, every)
 }
 
 // RunEveryAfter schedules a task to run at 'interval' intervals after a 'delay'.
@@ -88,9 +370,30 @@ func (s *Scheduler) RunEveryAfter(task Task, interval, delay time.Duration) {
 	s.schedule(task, s.after(delay), durationOf(interval))
 }
 
-// schedule schedules an event to be processed at a given time.
-func (s *Scheduler) schedule(event Task, when tick, repeat span) {
+// RunDynamic schedules a task for the next tick, which then decides on its own when
+// it should run next. If the task returns a positive delay, it is rescheduled to run
+// after that delay (at least a single tick), otherwise it is not executed again.
+func (s *Scheduler) RunDynamic(task Dynamic) {
+	s.scheduleDynamic(task, s.now())
+}
+
+// scheduleDynamic schedules a dynamic job to run first at a given time. It is then rescheduled
+// by the scheduler executing it, regardless of the maximum number of pending jobs.
+func (s *Scheduler) scheduleDynamic(task Dynamic, when tick) {
 	s.enqueueJob(job{
+		Next:  task,
+		RunAt: when,
+		Since: span(when - s.now()),
+	})
+}
+
+// schedule schedules an event to be processed at a given time, returns false if it was rejected.
+func (s *Scheduler) schedule(event Task, when tick, repeat span) bool {
+	if repeat != 0 && s.smoothing > 0 {
+		when = s.leastLoaded(when)
+	}
+
+	return s.enqueueJob(job{
 		Task:  event,
 		RunAt: when,
 		Since: span(when - s.now()),
@@ -98,62 +401,485 @@ func (s *Scheduler) schedule(event Task, when tick, repeat span) {
 	})
 }
 
-// enqueueJob adds a job to the queue.
-func (s *Scheduler) enqueueJob(job job) {
+// enqueueJob adds a newly scheduled job to the queue, returns false if it was rejected.
+func (s *Scheduler) enqueueJob(job job) bool {
+	if !s.admit() {
+		return false
+	}
+
+	s.place(job)
+	return true
+}
+
+// requeue adds a job to the queue, regardless of the maximum number of pending jobs.
+func (s *Scheduler) requeue(job job) {
+	s.counters.pending.Add(1)
+	s.place(job)
+}
+
+// place assigns the insertion sequence of a newly scheduled job and inserts it. The recurring
+// jobs are always assigned one, so that their order does not depend on how they were moved
+// across the buckets when rescheduled. The tasks scheduled earlier with Run are drained first
+// if the job is due on the next tick, so that the jobs submitted for the same tick execute in
+// the order in which they were submitted.
+func (s *Scheduler) place(job job) {
+	if (s.stable || job.Every != 0) && job.Seq&sequenceMask == 0 {
+		job.Seq |= s.seq.Add(1) & sequenceMask
+	}
+
+	if job.RunAt <= s.now() {
+		s.drain()
+	}
+
+	s.counters.scheduled.Add(1)
+	s.insert(job)
+}
+
+// admit reserves room for a new pending job, returns false and counts the job as rejected
+// if the maximum number of pending jobs would be exceeded.
+func (s *Scheduler) admit() bool {
+	if n := s.counters.pending.Add(1); s.maxJobs > 0 && n > s.maxJobs {
+		s.counters.pending.Add(-1)
+		s.counters.rejected.Add(1)
+		return false
+	}
+	return true
+}
+
+// insert adds a job into the bucket it is due in.
+func (s *Scheduler) insert(job job) {
 	bucket := s.bucketOf(job.RunAt)
+	if n := uint32(len(bucket.shards)); n > 0 {
+		shard := &bucket.shards[bucket.cursor.Add(1)%n]
+		shard.mu.Lock()
+		shard.queue = append(shard.queue, job)
+		shard.mu.Unlock()
+		return
+	}
+
 	bucket.mu.Lock()
 	bucket.queue = append(bucket.queue, job)
 	bucket.mu.Unlock()
 }
 
-// Seek advances the scheduler to a given time.
+// Seek advances the scheduler to a given time. When moving forward, the tasks which
+// became overdue are promoted so that they run on the next tick, regardless of how far
+// the clock jumped. A recurring task runs once for all of its missed occurrences, and
+// continues its cadence from there.
 func (s *Scheduler) Seek(t time.Time) {
-	s.next.Store(int64(tickOf(t)))
+	s.clock.anchor.Store(&anchor{when: t, at: s.clock.wall()})
+	now := tickOf(t)
+	if prev := tick(s.next.Swap(int64(now))); now > prev {
+		s.promote(now)
+	}
+}
+
+// Advance moves the scheduler's clock forward by 'd', rounded down to the resolution of the
+// clock. Unlike Seek, every intervening tick is processed so all of the tasks which become
+// due in between are executed, in order. It returns the new time of the scheduler's clock.
+func (s *Scheduler) Advance(d time.Duration) time.Time {
+	for i := durationOf(d); i > 0; i-- {
+		s.Tick()
+	}
+
+	return s.Now()
 }
 
-// Tick processes tasks for the current time and advances the internal clock.
+// Step advances the scheduler's clock by exactly 'dt' in a fixed-step simulation loop, executing
+// all of the tasks which become due in between, independently of the wall clock. Unlike Advance,
+// the remainder of the steps which don't fall on the resolution of the clock is carried over to
+// the next step, so that the steps add up exactly over time. It returns the new time of the clock.
+func (s *Scheduler) Step(dt time.Duration) time.Time {
+	total := time.Duration(s.carry.Add(int64(dt)))
+	ticks := total / resolution
+	s.carry.Add(-int64(ticks * resolution))
+	return s.Advance(ticks * resolution)
+}
+
+// promote moves the overdue jobs into the bucket of the current tick, and cascades the
+// coarse-grained jobs whose coarse tick was skipped into the wheel.
+func (s *Scheduler) promote(now tick) {
+	target := s.bucketOf(now)
+	overdue := make([]job, 0, 8)
+	for _, bucket := range s.buckets {
+		if bucket == target {
+			continue // already due on the next tick
+		}
+
+		offset := 0
+		bucket.mu.Lock()
+		bucket.merge()
+		for i, job := range bucket.queue {
+			if job.RunAt < now {
+				job.Since += span(now - job.RunAt)
+				job.RunAt = now
+				overdue = append(overdue, job)
+				continue
+			}
+
+			bucket.queue[offset] = bucket.queue[i]
+			offset++
+		}
+		clear(bucket.queue[offset:]) // release the closures of the removed jobs
+		bucket.queue = bucket.queue[:offset]
+		bucket.mu.Unlock()
+	}
+
+	if len(overdue) > 0 {
+		target.mu.Lock()
+		target.queue = append(target.queue, overdue...)
+		target.mu.Unlock()
+	}
+
+	for _, bucket := range s.coarse {
+		s.cascade(bucket, now)
+	}
+}
+
+// Tick processes tasks for the current time and advances the internal clock. It returns the
+// time of the processed tick, which is the tick index multiplied by the resolution since the
+// Unix epoch. Since Start seeks the clock to the wall clock, the time of a tick processed by
+// the running clock is the wall-clock boundary it is due at, and the tick is processed shortly
+// after that boundary. The time has no monotonic clock reading, so it should be compared with
+// time.Now using Sub, Before or After rather than with ==.
+//
+// The tasks due on the same tick run by priority, highest first. Within the same priority, the
+// one-shot tasks run first, in the order in which they were scheduled, followed by the recurring
+// tasks in the order in which they were first scheduled, however often they were rescheduled.
+// With WithStableOrder, all of the tasks run in the order in which they were first scheduled.
 func (s *Scheduler) Tick() time.Time {
+	due := s.CollectDue()
+	if s.executor == nil {
+		s.Execute(due)
+		return due.Time()
+	}
+
+	// Hand the due tasks over to the executor and wait until they have run. A panic of a task
+	// is raised again on this goroutine, so that it stops the clock just like without one.
+	done := make(chan any, 1)
+	s.executor(func() {
+		defer func() {
+			done <- recover()
+		}()
+		s.Execute(due)
+	})
+	if r := <-done; r != nil {
+		panic(r)
+	}
+	return due.Time()
+}
+
+// Due represents the jobs due on a tick, collected with CollectDue but not executed yet.
+type Due struct {
+	at   tick  // The tick the jobs are due on
+	jobs []job // The due jobs, in the order of their execution
+}
+
+// Time returns the time of the tick the jobs are due on.
+func (d Due) Time() time.Time {
+	return d.at.Time()
+}
+
+// Len returns the number of the due jobs.
+func (d Due) Len() int {
+	return len(d.jobs)
+}
+
+// CollectDue collects the jobs due at the current time and advances the internal clock,
+// without executing them. Together with Execute, this splits a Tick into two steps so that
+// the tasks can be executed on a specific goroutine, such as a render thread. Every collected
+// batch must be executed exactly once, before the same bucket of the wheel is collected again.
+func (s *Scheduler) CollectDue() Due {
 	tickNow := tick(s.next.Add(1) - 1)
-	timeNow := tickNow.Time()
+	s.lastTick.Store(time.Now().UnixNano())
+	if fn := s.hooks.before.Load(); fn != nil {
+		(*fn)(tickNow.Time())
+	}
+
+	if tickNow%coarseTicks == 0 {
+		s.cascade(s.coarseOf(tickNow), tickNow)
+	}
+
+	return Due{at: tickNow, jobs: s.collect(tickNow)}
+}
+
+// Execute executes the jobs collected with CollectDue on the calling goroutine, reschedules
+// the recurring ones according to the return values of their tasks, and returns how many
+// tasks were executed.
+func (s *Scheduler) Execute(due Due) int {
+	executed := s.execute(due.at, due.Time(), due.jobs)
+	if fn := s.hooks.after.Load(); fn != nil {
+		(*fn)(due.Time(), executed)
+	}
+
+	return executed
+}
+
+// collect moves the jobs due at the tick out of its bucket and returns them.
+func (s *Scheduler) collect(tickNow tick) []job {
 	bucket := s.bucketOf(tickNow)
-	offset := 0
 
+	// Move the due jobs into the spare buffer, so that the tasks can schedule into
+	// this bucket and the jobs scheduled for later remain visible while processing.
+	offset := 0
 	bucket.mu.Lock()
-	defer bucket.mu.Unlock()
-
-	for i, task := range bucket.queue {
-		if task.RunAt > tickNow { // scheduled for later
+	bucket.merge()
+	s.drainInto(bucket, tickNow)
+	bucket.peak = len(bucket.queue)
+	queue := bucket.spare[:0]
+	ordered := false
+	for i, job := range bucket.queue {
+		if job.RunAt > tickNow { // scheduled for later
 			bucket.queue[offset] = bucket.queue[i]
 			offset++
 			continue
 		}
 
-		// Process the task
-		repeat := task.Task(timeNow, task.Since.Duration())
+		queue = append(queue, job)
+		ordered = ordered || len(queue) > 1 && job.before(&queue[len(queue)-2])
+	}
+	clear(bucket.queue[offset:]) // release the closures of the removed jobs
+	bucket.queue = bucket.queue[:offset]
+	bucket.mu.Unlock()
+
+	// Order the due jobs by their priority and insertion sequence, if they are not already
+	if ordered {
+		sort.Stable(bySeq(queue))
+	}
+	return queue
+}
+
+// execute executes the due jobs outside of the critical section and returns how many tasks
+// were executed. If a task panics, the job of the task is ended, the jobs which were not
+// executed yet are postponed to the next tick and the panic is propagated to the caller.
+func (s *Scheduler) execute(tickNow tick, timeNow time.Time, queue []job) (executed int) {
+	bucket := s.bucketOf(tickNow)
+	defer s.compact(bucket)
+
+	var lateness sample
+	var inBucket, crossBucket uint64
+	var done int64
+	slow := s.hooks.slow.Load()
+	complete := s.hooks.complete.Load()
+	offset, current := 0, 0
+	defer func() {
+		r := recover()
+		var rest []job
+		if r != nil {
+			rest = s.abort(queue[current], queue[current+1:], complete)
+			executed++
+			done++
+		}
+
+		// Record the lateness of the executed tasks
+		s.latency.record(&lateness)
+		s.moves.record(inBucket, crossBucket)
+		s.counters.executed.Add(uint64(executed))
+		s.counters.pending.Add(-done)
+
+		// Merge back the jobs rescheduled into this bucket and keep the spare buffer. The jobs
+		// scheduled into this bucket for the current tick while it was being processed would
+		// otherwise wait for a full rotation of the wheel, so they are moved to the next tick.
+		bucket.mu.Lock()
+		bucket.merge()
+		late := append(s.takeDue(bucket, tickNow), rest...)
+		bucket.queue = append(bucket.queue, queue[:offset]...)
+		clear(queue) // release the closures of the executed jobs
+		bucket.spare = queue[:0]
+		bucket.mu.Unlock()
+
+		for _, job := range late {
+			job.Since += span(tickNow + 1 - job.RunAt)
+			job.RunAt = tickNow + 1
+			s.insert(job)
+		}
+
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	for i, task := range queue {
+		current = i
+
+		// Skip the task if it was cancelled in the meantime, or keep it in the wheel without
+		// executing it if it was suspended. A suspended one-shot task is kept in its bucket
+		// and checked again after a full rotation of the wheel.
+		repeat := true
+		switch {
+		case task.Handle == nil || task.Handle.begin():
+			if slow == nil {
+				repeat = task.run(timeNow, task.Since.Duration())
+			} else {
+				repeat = slow.run(&task, timeNow, task.Since.Duration())
+			}
+			lateness.Add(tickNow - task.RunAt)
+			executed++
+			if s.onFire != nil {
+				s.onFire(tickNow, task.RunAt)
+			}
+			if task.Handle != nil {
+				repeat = task.Handle.end(repeat && task.Every != 0)
+			}
+		case task.Handle.State() != Suspended:
+			if complete != nil && task.Every != 0 {
+				(*complete)(task.Handle)
+			}
+			done++
+			continue
+		case task.Every == 0:
+			task.RunAt = tickNow + tick(numBuckets)
+			queue[offset] = task
+			offset++
+			continue
+		}
 
 		// If the task is recurrent, determine how to reschedule it
-		if repeat && task.Every != 0 {
-			nextTick := tickNow + tick(task.Every)
-			switch {
-			case s.bucketOf(nextTick) == s.bucketOf(tickNow):
-				task.Since = span(nextTick - tickNow)
-				task.RunAt = nextTick
-				bucket.queue[offset] = task
-				offset++
-			default: // different bucket
-				s.enqueueJob(job{
-					Task:  task.Task,
-					RunAt: nextTick,
-					Since: task.Every,
-					Every: task.Every,
-				})
+		if !repeat || task.Every == 0 {
+			if complete != nil && task.Every != 0 {
+				(*complete)(task.Handle)
 			}
+			done++
+			continue
+		}
+
+		nextTick := tickNow + tick(task.Every)
+		switch {
+		case s.bucketOf(nextTick) == s.bucketOf(tickNow):
+			task.Since = span(nextTick - tickNow)
+			task.RunAt = nextTick
+			queue[offset] = task
+			offset++
+			inBucket++
+		default: // different bucket
+			crossBucket++
+			task.Since = span(nextTick - tickNow)
+			task.RunAt = nextTick
+			s.insert(task)
 		}
 	}
 
-	// Truncate the current bucket to remove processed events
+	return
+}
+
+// abort ends the job whose task has panicked, so that it is not executed again, and returns
+// a copy of the jobs which were due after it and were not executed.
+func (s *Scheduler) abort(failed job, rest []job, complete *func(*Handle)) []job {
+	if failed.Handle != nil {
+		failed.Handle.end(false)
+		if complete != nil && failed.Every != 0 {
+			(*complete)(failed.Handle)
+		}
+	}
+
+	return append([]job(nil), rest...)
+}
+
+// takeDue removes the jobs which are due at or before the tick from the bucket, must be
+// called while holding the lock of the bucket.
+func (s *Scheduler) takeDue(bucket *bucket, now tick) (due []job) {
+	offset := 0
+	for i, job := range bucket.queue {
+		if job.RunAt <= now {
+			due = append(due, job)
+			continue
+		}
+
+		bucket.queue[offset] = bucket.queue[i]
+		offset++
+	}
+	clear(bucket.queue[offset:]) // release the closures of the removed jobs
 	bucket.queue = bucket.queue[:offset]
-	return tickNow.Time()
+	return
+}
+
+// bySeq sorts the jobs by their priority, highest first, and then by their insertion sequence.
+type bySeq []job
+
+func (q bySeq) Len() int           { return len(q) }
+func (q bySeq) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q bySeq) Less(i, j int) bool { return q[i].before(&q[j]) }
+
+// before returns whether the job runs before the other one when both are due on the same tick.
+// The one-shot jobs have no insertion sequence unless the order is stable, so they run before
+// the recurring jobs of the same priority, which always have one.
+func (j *job) before(other *job) bool {
+	if pi, pj := j.Seq>>priorityShift, other.Seq>>priorityShift; pi != pj {
+		return pi > pj
+	}
+	return j.Seq < other.Seq
+}
+
+// leastLoaded returns the tick of the least loaded bucket within the smoothing tolerance
+// around the nominal tick, preferring the ones closest to the nominal tick.
+func (s *Scheduler) leastLoaded(nominal tick) tick {
+	now := s.now()
+	best, load := nominal, s.bucketOf(nominal).size()
+	for d := tick(1); d <= s.smoothing && load > 0; d++ {
+		for _, t := range [2]tick{nominal - d, nominal + d} {
+			if t < now {
+				continue
+			}
+
+			if n := s.bucketOf(t).size(); n < load {
+				best, load = t, n
+			}
+		}
+	}
+	return best
+}
+
+// size returns the number of jobs currently queued in the bucket.
+func (b *bucket) size() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.merge()
+	return len(b.queue)
+}
+
+// merge moves the jobs of the shards into the queue, must be called while holding the lock.
+func (b *bucket) merge() {
+	for i := range b.shards {
+		shard := &b.shards[i]
+		shard.mu.Lock()
+		b.queue = append(b.queue, shard.queue...)
+		clear(shard.queue)
+		shard.queue = shard.queue[:0]
+		shard.mu.Unlock()
+	}
+}
+
+// compact shrinks the queue of the bucket if it stayed well below its capacity for
+// a number of rotations. This is skipped if the bucket is currently contended.
+func (s *Scheduler) compact(bucket *bucket) {
+	if s.shrink.rotations <= 0 || !bucket.mu.TryLock() {
+		return
+	}
+
+	defer bucket.mu.Unlock()
+	// The queue is measured at its peak, before the due jobs were collected, so that a queue
+	// which is drained and refilled on every rotation is not shrunk only to grow back again.
+	size, capacity := len(bucket.queue), cap(bucket.queue)
+	if capacity <= minCapacity || float64(max(size, bucket.peak)) >= s.shrink.ratio*float64(capacity) {
+		bucket.idle = 0
+		return
+	}
+
+	// Reallocate to a smaller backing array after enough idle rotations
+	if bucket.idle++; bucket.idle >= s.shrink.rotations {
+		capacity /= 2
+		if capacity < size {
+			capacity = size
+		}
+		if capacity < minCapacity {
+			capacity = minCapacity
+		}
+
+		queue := make([]job, size, capacity)
+		copy(queue, bucket.queue)
+		bucket.queue = queue
+		bucket.idle = 0
+	}
 }
 
 // bucketOf returns the bucket index for a given tick.
@@ -164,6 +890,59 @@ func (s *Scheduler) bucketOf(when tick) *bucket {
 
 // ----------------------------------------- Clock -----------------------------------------
 
+// Now returns the current time of the scheduler's clock, which may differ from
+// the wall clock if the scheduler was moved with Seek.
+func (s *Scheduler) Now() time.Time {
+	return s.now().Time()
+}
+
+// CurrentTick returns the index of the tick which is currently being processed, or of the
+// last processed one between ticks. Ticks are counted from the Unix epoch at the resolution
+// of the clock, so the index remains stable and deterministic after a Seek.
+func (s *Scheduler) CurrentTick() int64 {
+	return int64(s.now() - 1)
+}
+
+// Drift returns how far the time elapsed since the clock was last set with Seek or Start is
+// ahead of the time of the last processed tick. While the internal clock keeps up, the drift
+// stays below the resolution of the clock, and a growing drift means that the clock is falling
+// behind, in which case it can be aligned again with Seek or WithResync. The elapsed time is
+// measured with the monotonic clock, so the drift is not affected by the adjustments of the
+// wall clock, such as NTP corrections. If the clock was never set, the wall clock is used.
+func (s *Scheduler) Drift() time.Duration {
+	return s.elapsed().Sub((s.now() - 1).Time())
+}
+
+// elapsed returns the time the scheduler's clock should be at, given the time elapsed since
+// it was last set.
+func (s *Scheduler) elapsed() time.Time {
+	now := s.clock.wall()
+	if a := s.clock.anchor.Load(); a != nil {
+		return a.when.Add(now.Sub(a.at))
+	}
+	return now
+}
+
+// realign moves the clock forward if it has fallen behind by more than the threshold set with
+// WithResync. The clock is never moved backwards, so that the ticks keep advancing monotonically
+// even if the wall clock jumps backwards.
+func (s *Scheduler) realign() {
+	if s.resync > 0 && s.Drift() > s.resync {
+		s.Seek(s.elapsed())
+	}
+}
+
+// Resolution returns the resolution of the scheduler's clock. The execution times of
+// all of the tasks are rounded to this resolution, so shorter intervals can't be represented.
+func (s *Scheduler) Resolution() time.Duration {
+	return resolution
+}
+
+// Window returns the time window covered by a single rotation of the timing wheel.
+func (s *Scheduler) Window() time.Duration {
+	return time.Duration(len(s.buckets)) * resolution
+}
+
 // now returns the current tick.
 func (s *Scheduler) now() tick {
 	return tick(s.next.Load())
@@ -175,42 +954,144 @@ func (s *Scheduler) after(dt time.Duration) tick {
 }
 
 // alignedAt calculates the next tick boundary based on the current tick and the desired interval.
+// If the current tick is already on a boundary, the current tick is returned.
 func (s *Scheduler) alignedAt(i time.Duration) tick {
 	current := s.now()
 	interval := tick(durationOf(i))
+	if current%interval == 0 {
+		return current
+	}
+
 	return current + interval - current%interval
 }
 
+// ErrStarted is returned when starting a scheduler whose internal clock is already running.
+var ErrStarted = errors.New("timeline: scheduler is already started")
+
 // Start begins the scheduler's internal clock, aligning with the specified
-// 'interval'. It returns a cancel function to stop the clock.
-func (s *Scheduler) Start(ctx context.Context) context.CancelFunc {
-	interval := resolution
+// 'interval'. It blocks until the next resolution boundary, or the boundary set with
+// WithStartAlignment, and returns once the first tick has been processed, so the caller
+// can synchronize with the clock. It returns a cancel function to stop the clock, or
+// ErrStarted if the clock is already running. If a task panics, the clock is stopped and
+// the callback registered with OnStop is invoked with the error, after which the clock
+// can be started again. Once started, the clock advances by exactly one tick per interval
+// of the monotonic clock, so the adjustments of the wall clock, such as NTP corrections,
+// neither move it backwards nor make it skip ticks.
+func (s *Scheduler) Start(ctx context.Context) (context.CancelFunc, error) {
+	cancel, ready, err := s.start(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	<-ready
+	return cancel, nil
+}
+
+// StartAsync begins the scheduler's internal clock, similarly to Start, but returns
+// immediately without blocking the calling goroutine, waiting for the next resolution
+// boundary in the background instead.
+func (s *Scheduler) StartAsync(ctx context.Context) (context.CancelFunc, error) {
+	cancel, _, err := s.start(ctx)
+	return cancel, err
+}
+
+// MustStart begins the scheduler's internal clock, similarly to Start, but panics
+// if the clock can't be started.
+func (s *Scheduler) MustStart(ctx context.Context) context.CancelFunc {
+	cancel, err := s.Start(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return cancel
+}
+
+// start begins the internal clock and returns a channel which is closed once the
+// first tick has been processed.
+func (s *Scheduler) start(ctx context.Context) (context.CancelFunc, chan struct{}, error) {
+	s.clock.mu.Lock()
+	if s.clock.cancel != nil {
+		s.clock.mu.Unlock()
+		return nil, nil, ErrStarted
+	}
+
+	interval := s.alignment
 	ctx, cancel := context.WithCancel(ctx)
+	s.clock.cancel = cancel
+	s.clock.mu.Unlock()
 
-	// Align the scheduler's internal clock with the nearest resolution boundary
-	now := time.Now()
+	// Align the scheduler's internal clock with the next alignment boundary
+	now := s.clock.wall()
 	next := now.Truncate(interval).Add(interval)
 	s.Seek(next)
+	s.clock.anchor.Store(&anchor{when: now, at: now})
 
-	// Wait until the next resolution boundary
-	time.Sleep(next.Sub(now))
+	// Start the ticker once the next alignment boundary is reached
+	ready := make(chan struct{})
+	go s.run(ctx, cancel, ready, next.Sub(now))
+	return cancel, ready, nil
+}
 
-	// Start the ticker
-	ticker := time.NewTicker(interval)
-	s.Tick()
-	go func() {
-		for {
-			select {
-			case <-ticker.C:
-				s.Tick()
-			case <-ctx.Done():
-				ticker.Stop()
-				return
-			}
+// Started returns whether the internal clock of the scheduler is currently running.
+func (s *Scheduler) Started() bool {
+	s.clock.mu.Lock()
+	defer s.clock.mu.Unlock()
+	return s.clock.cancel != nil
+}
+
+// Healthy returns whether the scheduler has processed a tick within the last 'within'
+// wall-clock duration. It returns false if the clock has stalled, for example because a
+// task is blocking the clock, or if no tick was ever processed.
+func (s *Scheduler) Healthy(within time.Duration) bool {
+	last := s.lastTick.Load()
+	return last != 0 && time.Since(time.Unix(0, last)) <= within
+}
+
+// run ticks the clock until the context is cancelled or a task panics.
+func (s *Scheduler) run(ctx context.Context, cancel context.CancelFunc, ready chan struct{}, wait time.Duration) {
+	time.Sleep(wait)
+
+	// The tasks scheduled for a wall-clock time while waiting for the alignment boundary are
+	// already behind the clock, so they are caught up on the first tick rather than a rotation later
+	s.promote(s.now())
+	ticker := time.NewTicker(resolution)
+	err := s.loop(ctx, ticker.C, ready)
+	ticker.Stop()
+	cancel()
+
+	// Unblock the caller of Start if the very first tick has panicked
+	select {
+	case <-ready:
+	default:
+		close(ready)
+	}
+
+	s.clock.mu.Lock()
+	s.clock.cancel = nil
+	s.clock.mu.Unlock()
+	if fn := s.hooks.stop.Load(); fn != nil {
+		(*fn)(err)
+	}
+}
+
+// loop processes the ticks until the context is cancelled, recovering from a panic.
+func (s *Scheduler) loop(ctx context.Context, ticks <-chan time.Time, ready chan struct{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("timeline: clock stopped due to a panic: %v", r)
 		}
 	}()
 
-	return cancel
+	s.Tick()
+	close(ready)
+	for {
+		select {
+		case <-ticks:
+			s.Tick()
+			s.realign()
+		case <-ctx.Done():
+			return nil
+		}
+	}
 }
 
 // ----------------------------------------- Time (in ticks) -----------------------------------------
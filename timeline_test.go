@@ -6,6 +6,7 @@ package timeline
 import (
 	"context"
 	"fmt"
+	"runtime"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -25,12 +26,12 @@ BenchmarkRun/next/100-24       	  685710	      1746 ns/op	        68.57 million/
 BenchmarkRun/next/1000-24      	   70587	     17213 ns/op	        70.59 million/op	     105 B/op	       0 allocs/op
 BenchmarkRun/next/10000-24     	    6966	    170543 ns/op	        69.66 million/op	   15890 B/op	       0 allocs/op
 BenchmarkRun/next/100000-24    	     514	   2074903 ns/op	        51.40 million/op	 2738303 B/op	       4 allocs/op
-BenchmarkRun/after/1-24        	31168992	        38.53 ns/op	        31.17 million/op	       0 B/op	       0 allocs/op
-BenchmarkRun/after/10-24       	 6045394	       198.9 ns/op	        60.45 million/op	       0 B/op	       0 allocs/op
-BenchmarkRun/after/100-24      	  685732	      1761 ns/op	        68.57 million/op	       0 B/op	       0 allocs/op
-BenchmarkRun/after/1000-24     	   49078	     23361 ns/op	        48.58 million/op	    1220 B/op	       0 allocs/op
-BenchmarkRun/after/10000-24    	    3808	    730699 ns/op	         7.252 million/op	 1168807 B/op	       0 allocs/op
-BenchmarkRun/after/100000-24   	     369	   3436339 ns/op	         0.06827 million/op	12061832 B/op	       7 allocs/op
+BenchmarkRun/after/1        	 2996012	       359.5 ns/op	         2.996 million/op	       0 B/op	       0 allocs/op
+BenchmarkRun/after/10       	  923886	      1175 ns/op	         9.239 million/op	       0 B/op	       0 allocs/op
+BenchmarkRun/after/100      	  147718	      9431 ns/op	        14.77 million/op	       0 B/op	       0 allocs/op
+BenchmarkRun/after/1000     	    8062	    150366 ns/op	         8.062 million/op	       0 B/op	       0 allocs/op
+BenchmarkRun/after/10000    	     718	   1707287 ns/op	         7.180 million/op	       0 B/op	       0 allocs/op
+BenchmarkRun/after/100000   	      96	  14072635 ns/op	         9.600 million/op	       0 B/op	       0 allocs/op
 */
 func BenchmarkRun(b *testing.B) {
 	work := func(time.Time, time.Duration) bool {
@@ -58,23 +59,61 @@ func BenchmarkRun(b *testing.B) {
 
 	for _, size := range []int{1, 10, 100, 1000, 10000, 100000} {
 		b.Run(fmt.Sprintf("after/%d", size), func(b *testing.B) {
-			counter.Store(0)
 			s := New()
-			b.ReportAllocs()
-			b.ResetTimer()
-
-			for n := 0; n < b.N; n++ {
+			schedule := func() {
 				for i := 0; i < size; i++ {
-					s.RunAfter(work, time.Duration(10*i)*time.Millisecond)
+					s.RunAfter(work, time.Duration(10*(i%numBuckets))*time.Millisecond)
 				}
 				s.Tick()
 			}
 
+			// Warm up until the wheel reaches its steady state
+			for n := 0; n < 2*numBuckets; n++ {
+				schedule()
+			}
+
+			counter.Store(0)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				schedule()
+			}
+
 			b.ReportMetric(float64(counter.Load())/1000000, "million/op")
 		})
 	}
 }
 
+func BenchmarkConcurrent(b *testing.B) {
+	work := func(time.Time, time.Duration) bool {
+		return false
+	}
+
+	b.Run("next", func(b *testing.B) {
+		s := New()
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				s.Run(work)
+			}
+		})
+	})
+
+	for _, shards := range []int{1, 8} {
+		b.Run(fmt.Sprintf("after/%d", shards), func(b *testing.B) {
+			s := New(WithShards(shards))
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					s.RunAfter(work, 100*time.Millisecond)
+				}
+			})
+		})
+	}
+}
+
 func TestRunAt(t *testing.T) {
 	now := time.Unix(0, 0)
 	log := make(Log, 0, 8)
@@ -119,6 +158,63 @@ func TestRunAfter(t *testing.T) {
 	}, log)
 }
 
+func TestRunInTicks(t *testing.T) {
+	now := time.Unix(0, 0)
+	var ticks []int64
+
+	s := newScheduler(now)
+	for _, n := range []int{150, 3, 0, -1} {
+		s.RunInTicks(func(now time.Time, elapsed time.Duration) bool {
+			ticks = append(ticks, now.UnixMilli()/10)
+			return true
+		}, n)
+	}
+
+	s.Advance(2 * time.Second)
+	assert.Equal(t, []int64{0, 0, 3, 150}, ticks)
+}
+
+func TestRunOnceAt(t *testing.T) {
+	now := time.Unix(0, 0)
+	log := make(Log, 0, 8)
+
+	s := newScheduler(now)
+	s.RunOnceAt(log.Log("Past"), now.Add(-time.Second))
+	s.RunOnceAt(log.Log("Future"), now.Add(500*time.Millisecond))
+	s.Tick()
+	assert.Equal(t, Log{"Past"}, log)
+
+	// Seek past the scheduled one-shot, it must still fire on the next tick
+	s.Seek(now.Add(2 * time.Second))
+	s.Tick()
+	assert.Equal(t, Log{"Past", "Future"}, log)
+
+	// And never again
+	for i := 0; i < 200; i++ {
+		s.Tick()
+	}
+	assert.Equal(t, Log{"Past", "Future"}, log)
+}
+
+func TestSeekRecurring(t *testing.T) {
+	now := time.Unix(0, 0)
+	var count Counter
+
+	s := newScheduler(now)
+	s.RunEvery(count.Inc(), 5*time.Second)
+
+	// Seek over multiple windows, the overdue task runs once on the next tick
+	s.Seek(now.Add(12500 * time.Millisecond))
+	s.Tick()
+	assert.Equal(t, 1, count.Value())
+
+	// And continues its cadence from there
+	s.Advance(4990 * time.Millisecond)
+	assert.Equal(t, 1, count.Value())
+	s.Advance(10 * time.Millisecond)
+	assert.Equal(t, 2, count.Value())
+}
+
 func TestRunEveryAt(t *testing.T) {
 	now := time.Unix(0, 0)
 	var count Counter
@@ -134,6 +230,31 @@ func TestRunEveryAt(t *testing.T) {
 	assert.Equal(t, 12, count.Value())
 }
 
+func TestRunEveryAtPast(t *testing.T) {
+	now := time.Unix(10, 0)
+	var runs []time.Time
+	record := func(now time.Time, elapsed time.Duration) bool {
+		runs = append(runs, now)
+		return true
+	}
+
+	// The first run keeps the phase of the start time
+	s := newScheduler(now)
+	s.RunEveryAt(record, 300*time.Millisecond, now.Add(-time.Second))
+	s.Advance(600 * time.Millisecond)
+	assert.Equal(t, []time.Time{
+		now.Add(200 * time.Millisecond),
+		now.Add(500 * time.Millisecond),
+	}, runs)
+
+	// A start time on the boundary runs on the current tick
+	runs = runs[:0]
+	s = newScheduler(now)
+	s.RunEveryAt(record, 500*time.Millisecond, now.Add(-time.Second))
+	s.Tick()
+	assert.Equal(t, []time.Time{now}, runs)
+}
+
 func TestRunEveryAfter(t *testing.T) {
 	now := time.Unix(0, 0)
 	var count Counter
@@ -160,7 +281,7 @@ func TestRunEvery10ms(t *testing.T) {
 		s.Tick()
 	}
 
-	assert.Equal(t, 9, count.Value())
+	assert.Equal(t, 10, count.Value())
 }
 
 func TestRunEvery1s(t *testing.T) {
@@ -174,7 +295,176 @@ func TestRunEvery1s(t *testing.T) {
 		s.Tick()
 	}
 
-	assert.Equal(t, 5, count.Value())
+	assert.Equal(t, 6, count.Value())
+}
+
+func TestRunEveryAligned(t *testing.T) {
+	now := time.Unix(1, 0)
+	log := make([]time.Time, 0, 4)
+
+	s := newScheduler(now)
+	s.RunEvery(func(now time.Time, elapsed time.Duration) bool {
+		assert.Equal(t, time.Second, elapsed)
+		log = append(log, now)
+		return true
+	}, 1*time.Second)
+
+	for i := 0; i < 110; i++ {
+		s.Tick()
+	}
+
+	assert.Equal(t, []time.Time{
+		time.Unix(1, 0),
+		time.Unix(2, 0),
+	}, log)
+}
+
+func TestRunEveryUnaligned(t *testing.T) {
+	now := time.Unix(1, int64(230*time.Millisecond))
+	log := make([]time.Time, 0, 4)
+
+	s := newScheduler(now)
+	s.RunEvery(func(now time.Time, elapsed time.Duration) bool {
+		assert.Equal(t, time.Second, elapsed)
+		log = append(log, now)
+		return true
+	}, 1*time.Second)
+
+	for i := 0; i < 110; i++ {
+		s.Tick()
+	}
+
+	assert.Equal(t, []time.Time{
+		time.Unix(2, 0),
+	}, log)
+}
+
+func TestRunEveryImmediately(t *testing.T) {
+	now := time.Unix(1, int64(230*time.Millisecond))
+	log := make([]time.Time, 0, 4)
+
+	s := newScheduler(now)
+	s.RunEvery(func(now time.Time, elapsed time.Duration) bool {
+		log = append(log, now)
+		return true
+	}, 1*time.Second, Immediately)
+
+	for i := 0; i < 110; i++ {
+		s.Tick()
+	}
+
+	assert.Equal(t, []time.Time{
+		time.Unix(1, int64(230*time.Millisecond)),
+		time.Unix(2, int64(230*time.Millisecond)),
+	}, log)
+}
+
+func TestRunAtNextBoundary(t *testing.T) {
+	for _, tc := range []struct {
+		now       time.Time
+		inclusive bool
+		expect    Log
+	}{
+		{now: time.Unix(1, 230*1e6), expect: Log{"2.000"}},
+		{now: time.Unix(1, 230*1e6), inclusive: true, expect: Log{"2.000"}},
+		{now: time.Unix(1, 0), expect: Log{"2.000"}},
+		{now: time.Unix(1, 0), inclusive: true, expect: Log{"1.000"}},
+	} {
+		log := make(Log, 0, 2)
+		s := newScheduler(tc.now)
+		s.RunAtNextBoundary(func(now time.Time, elapsed time.Duration) bool {
+			log = append(log, fmt.Sprintf("%d.%03d", now.Unix(), now.UnixMilli()%1000))
+			return true
+		}, time.Second, tc.inclusive)
+
+		s.Advance(3 * time.Second)
+		assert.Equal(t, tc.expect, log)
+	}
+}
+
+func TestRunDynamic(t *testing.T) {
+	now := time.Unix(0, 0)
+	log := make([]time.Duration, 0, 8)
+
+	// Exponential backoff, starting with sub-resolution delay
+	delay := 5 * time.Millisecond
+	s := newScheduler(now)
+	s.RunDynamic(func(now time.Time, elapsed time.Duration) time.Duration {
+		log = append(log, elapsed)
+		if delay *= 2; delay > time.Second {
+			return 0
+		}
+		return delay
+	})
+
+	for i := 0; i < 300; i++ {
+		s.Tick()
+	}
+
+	assert.Equal(t, []time.Duration{
+		0,
+		10 * time.Millisecond,
+		20 * time.Millisecond,
+		40 * time.Millisecond,
+		80 * time.Millisecond,
+		160 * time.Millisecond,
+		320 * time.Millisecond,
+		640 * time.Millisecond,
+	}, log)
+}
+
+func TestRunDynamicClamp(t *testing.T) {
+	now := time.Unix(0, 0)
+	var count Counter
+
+	s := newScheduler(now)
+	s.RunDynamic(func(now time.Time, elapsed time.Duration) time.Duration {
+		count.Inc()(now, elapsed)
+		return time.Millisecond
+	})
+
+	for i := 0; i < 10; i++ {
+		s.Tick()
+	}
+	assert.Equal(t, 10, count.Value())
+}
+
+func TestRunDynamicSameBucket(t *testing.T) {
+	now := time.Unix(0, 0)
+	var count Counter
+
+	s := newScheduler(now)
+	s.RunDynamic(func(now time.Time, elapsed time.Duration) time.Duration {
+		count.Inc()(now, elapsed)
+		return time.Second
+	})
+
+	for i := 0; i < 201; i++ {
+		s.Tick()
+	}
+	assert.Equal(t, 3, count.Value())
+}
+
+func TestRunDuringTickSameBucket(t *testing.T) {
+	for _, options := range [][]Option{nil, {WithShards(4)}, {WithStableOrder()}} {
+		now := time.Unix(0, 0)
+		var count Counter
+
+		// Tasks scheduling into the bucket which is currently being processed
+		s := newScheduler(now, options...)
+		s.Run(func(now time.Time, elapsed time.Duration) bool {
+			s.RunAt(count.Inc(), now)
+			s.RunAt(count.Inc(), now.Add(-5*time.Millisecond))
+			s.Schedule(count.Inc(), now, 0)
+			s.Run(count.Inc())
+			return true
+		})
+
+		s.Tick()
+		assert.Equal(t, 0, count.Value())
+		s.Tick()
+		assert.Equal(t, 4, count.Value())
+	}
 }
 
 func TestRun(t *testing.T) {
@@ -192,11 +482,29 @@ func TestRun(t *testing.T) {
 	assert.Equal(t, 2, count.Value())
 }
 
+func TestRunFIFO(t *testing.T) {
+	now := time.Unix(0, 0)
+	log := make(Log, 0, 8)
+
+	// Tasks submitted for the same tick run in program order, regardless of the path
+	s := newScheduler(now)
+	s.Run(log.Log("A"))
+	s.RunAfter(log.Log("B"), 0)
+	s.Run(log.Log("C"))
+	s.RunAt(log.Log("D"), s.Now())
+	s.Run(log.Log("E"))
+	s.Schedule(log.Log("F"), s.Now(), 0)
+	s.Run(log.Log("G"))
+
+	s.Tick()
+	assert.Equal(t, Log{"A", "B", "C", "D", "E", "F", "G"}, log)
+}
+
 func TestElapsed(t *testing.T) {
 	s := New()
 
 	var wg sync.WaitGroup
-	wg.Add(3)
+	wg.Add(4)
 	s.RunEvery(func(now time.Time, elapsed time.Duration) bool {
 		fmt.Printf("Tick at %02d.%03d, elapsed=%v\n",
 			now.Second(), now.UnixMilli()%1000, elapsed)
@@ -230,7 +538,9 @@ func TestTickOf(t *testing.T) {
 
 func TestStart(t *testing.T) {
 	s := New()
-	defer s.Start(context.Background())()
+	cancel, err := s.Start(context.Background())
+	assert.NoError(t, err)
+	defer cancel()
 
 	var count Counter
 	s.RunAfter(count.Inc(), 30*time.Millisecond)
@@ -241,9 +551,469 @@ func TestStart(t *testing.T) {
 	assert.Equal(t, 3, count.Value())
 }
 
+func TestStartAlignment(t *testing.T) {
+	var first atomic.Int64
+	s := New(WithStartAlignment(200 * time.Millisecond))
+	s.BeforeTick(func(now time.Time) {
+		first.CompareAndSwap(0, now.UnixNano())
+	})
+
+	cancel, err := s.Start(context.Background())
+	assert.NoError(t, err)
+	defer cancel()
+	assert.Zero(t, first.Load()%int64(200*time.Millisecond))
+}
+
+func TestStartCatchUp(t *testing.T) {
+	var count Counter
+	s := New(WithStartAlignment(100 * time.Millisecond))
+	s.RunAt(count.Inc(), time.Now().Add(5*time.Millisecond))
+
+	// The task scheduled before the first tick runs on it
+	cancel, err := s.Start(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count.Value())
+	cancel()
+}
+
+func TestStartAsyncCatchUp(t *testing.T) {
+	var count Counter
+	s := New(WithStartAlignment(100 * time.Millisecond))
+	cancel, err := s.StartAsync(context.Background())
+	assert.NoError(t, err)
+	defer cancel()
+
+	// The task scheduled while waiting for the boundary does not wait for a rotation
+	s.RunAt(count.Inc(), time.Now().Add(5*time.Millisecond))
+	assert.Eventually(t, func() bool {
+		return count.Value() == 1
+	}, 500*time.Millisecond, time.Millisecond)
+}
+
+func TestCurrentTick(t *testing.T) {
+	s := newScheduler(time.Unix(1, 0))
+	log := make([]int64, 0, 4)
+	s.RunEvery(func(now time.Time, elapsed time.Duration) bool {
+		log = append(log, s.CurrentTick())
+		return true
+	}, 20*time.Millisecond)
+
+	s.Advance(50 * time.Millisecond)
+	assert.Equal(t, []int64{100, 102, 104}, log)
+	assert.Equal(t, int64(104), s.CurrentTick())
+}
+
+func TestResolution(t *testing.T) {
+	s := New()
+	assert.Equal(t, 10*time.Millisecond, s.Resolution())
+	assert.Equal(t, time.Second, s.Window())
+}
+
+func TestStartTwice(t *testing.T) {
+	s := New()
+	assert.False(t, s.Started())
+	cancel, err := s.Start(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, s.Started())
+
+	// A second call does not spawn a duplicate ticker
+	_, err = s.Start(context.Background())
+	assert.ErrorIs(t, err, ErrStarted)
+	_, err = s.StartAsync(context.Background())
+	assert.ErrorIs(t, err, ErrStarted)
+	assert.Panics(t, func() {
+		s.MustStart(context.Background())
+	})
+
+	var count Counter
+	s.RunEvery(count.Inc(), 10*time.Millisecond)
+	time.Sleep(105 * time.Millisecond)
+	assert.InDelta(t, 10, count.Value(), 2)
+
+	// Once stopped, it can be started again
+	stopped := make(chan error, 1)
+	s.OnStop(func(err error) {
+		stopped <- err
+	})
+	cancel()
+	assert.NoError(t, <-stopped)
+	assert.False(t, s.Started())
+}
+
+func TestStartAsync(t *testing.T) {
+	s := New()
+	var count Counter
+	cancel, err := s.StartAsync(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, s.Started())
+	defer cancel()
+
+	// The task scheduled before the first tick is executed
+	s.Run(count.Inc())
+	assert.Eventually(t, func() bool {
+		return count.Value() == 1
+	}, time.Second, time.Millisecond)
+}
+
+func TestStartPanic(t *testing.T) {
+	stopped := make(chan error, 1)
+	s := New()
+	s.OnStop(func(err error) {
+		stopped <- err
+	})
+
+	// A task panics, which stops the clock
+	_, err := s.Start(context.Background())
+	assert.NoError(t, err)
+	s.RunAfter(func(now time.Time, elapsed time.Duration) bool {
+		panic("boom")
+	}, 20*time.Millisecond)
+	assert.EqualError(t, <-stopped, "timeline: clock stopped due to a panic: boom")
+	assert.False(t, s.Started())
+
+	// The clock can be restarted
+	var count Counter
+	s.Run(count.Inc())
+	cancel, err := s.Start(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count.Value())
+
+	cancel()
+	assert.NoError(t, <-stopped)
+}
+
+func TestTickPanic(t *testing.T) {
+	now := time.Unix(0, 0)
+	var every, once Counter
+
+	s := newScheduler(now)
+	s.RunEvery(every.Inc(), 10*time.Millisecond)
+	h := s.Schedule(func(now time.Time, elapsed time.Duration) bool {
+		panic("boom")
+	}, now, 0)
+	s.Run(once.Inc())
+
+	// The panic is propagated, but the job is ended and the other jobs are kept
+	assert.PanicsWithValue(t, "boom", func() { s.Tick() })
+	assert.Equal(t, Done, h.State())
+	assert.Equal(t, 0, every.Value()+once.Value())
+	assert.Equal(t, 2, s.Stats().Pending)
+
+	// The jobs which were not executed run on the next tick
+	s.Advance(30 * time.Millisecond)
+	assert.Equal(t, 3, every.Value())
+	assert.Equal(t, 1, once.Value())
+	assert.Equal(t, 1, s.Stats().Pending)
+}
+
+func TestHealthy(t *testing.T) {
+	s := New()
+	assert.False(t, s.Healthy(time.Second))
+
+	cancel, err := s.Start(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, s.Healthy(time.Second))
+
+	// A blocking task stalls the clock
+	release := make(chan struct{})
+	s.Run(func(now time.Time, elapsed time.Duration) bool {
+		<-release
+		return true
+	})
+	assert.Eventually(t, func() bool {
+		return !s.Healthy(50 * time.Millisecond)
+	}, time.Second, time.Millisecond)
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		return s.Healthy(50 * time.Millisecond)
+	}, time.Second, time.Millisecond)
+	cancel()
+}
+
+func TestTickWallClock(t *testing.T) {
+	ticks := make(chan time.Time, 1)
+	s := New(WithStartAlignment(100 * time.Millisecond))
+
+	// The time of a tick processed by the running clock is the wall-clock boundary it is due at
+	s.BeforeTick(func(now time.Time) {
+		select {
+		case ticks <- now:
+		default:
+		}
+	})
+
+	cancel, err := s.Start(context.Background())
+	assert.NoError(t, err)
+	defer cancel()
+
+	for i := 0; i < 5; i++ {
+		tick := <-ticks
+		assert.Zero(t, tick.UnixNano()%int64(10*time.Millisecond))
+		assert.InDelta(t, 0, time.Since(tick), float64(50*time.Millisecond))
+		assert.False(t, tick.After(time.Now()))
+	}
+}
+
+func TestDrift(t *testing.T) {
+	s := New()
+	s.Seek(time.Now())
+	time.Sleep(100 * time.Millisecond)
+	s.Tick()
+	assert.InDelta(t, 100*time.Millisecond, s.Drift(), float64(50*time.Millisecond))
+}
+
+func TestResync(t *testing.T) {
+	s := New(WithResync(50 * time.Millisecond))
+	cancel, err := s.Start(context.Background())
+	assert.NoError(t, err)
+	defer cancel()
+
+	// Stall the clock, the ticks it missed are dropped by the ticker
+	s.Run(func(time.Time, time.Duration) bool {
+		time.Sleep(500 * time.Millisecond)
+		return false
+	})
+
+	// It is aligned again once it is no longer stalled
+	time.Sleep(600 * time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return s.Drift() < 50*time.Millisecond
+	}, time.Second, time.Millisecond)
+}
+
+func TestResyncBackwards(t *testing.T) {
+	wall := time.Unix(1000, 0)
+	s := New(WithResync(50 * time.Millisecond))
+	s.clock.wall = func() time.Time { return wall }
+	s.Seek(wall)
+
+	// The wall clock jumps an hour backwards, the ticks keep advancing
+	wall = wall.Add(-time.Hour)
+	last := s.CurrentTick()
+	for i := 0; i < 10; i++ {
+		s.Tick()
+		s.realign()
+		assert.Equal(t, last+1, s.CurrentTick())
+		last = s.CurrentTick()
+	}
+
+	// The wall clock jumps forward, the clock catches up
+	wall = wall.Add(2 * time.Hour)
+	s.Tick()
+	s.realign()
+	assert.Equal(t, time.Unix(1000, 0).Add(time.Hour), s.Now())
+}
+
+func TestNow(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newScheduler(now)
+	assert.Equal(t, now, s.Now())
+
+	s.Tick()
+	assert.Equal(t, now.Add(10*time.Millisecond), s.Now())
+
+	s.Seek(now.Add(time.Hour))
+	assert.Equal(t, now.Add(time.Hour), s.Now())
+}
+
+func TestShrink(t *testing.T) {
+	now := time.Unix(0, 0)
+	var count Counter
+
+	s := newScheduler(now, WithShrink(0.25, 2))
+	for i := 0; i < 1000; i++ {
+		s.Run(count.Inc())
+	}
+
+	s.Tick()
+	assert.Equal(t, 1000, count.Value())
+	assert.GreaterOrEqual(t, cap(s.buckets[0].queue), 1000)
+
+	// Rotate the wheel several times, the bucket should shrink back
+	for i := 0; i < 20*numBuckets; i++ {
+		s.Tick()
+	}
+	assert.Equal(t, minCapacity, cap(s.buckets[0].queue))
+}
+
+func TestShrinkSustained(t *testing.T) {
+	s := New()
+	schedule := func() {
+		for i := 0; i < 1000; i++ {
+			s.RunAfter(func(time.Time, time.Duration) bool {
+				return true
+			}, time.Duration(10*(i%numBuckets))*time.Millisecond)
+		}
+		s.Tick()
+	}
+
+	// Warm up until the wheel reaches its steady state
+	for i := 0; i < 2*numBuckets; i++ {
+		schedule()
+	}
+
+	// Under a sustained load, the buckets are neither shrunk nor grown again
+	for i := 0; i < 20*numBuckets; i++ {
+		assert.Zero(t, testing.AllocsPerRun(1, schedule))
+	}
+}
+
+func TestShrinkDisabled(t *testing.T) {
+	now := time.Unix(0, 0)
+	var count Counter
+
+	s := newScheduler(now, WithShrink(0.25, 0))
+	for i := 0; i < 1000; i++ {
+		s.Run(count.Inc())
+	}
+
+	for i := 0; i < 20*numBuckets; i++ {
+		s.Tick()
+	}
+	assert.GreaterOrEqual(t, cap(s.buckets[0].queue), 1000)
+}
+
+func TestClone(t *testing.T) {
+	now := time.Unix(0, 0)
+	var count Counter
+
+	s := newScheduler(now)
+	s.RunEvery(count.Inc(), 10*time.Millisecond)
+	s.RunAfter(count.Inc(), 1500*time.Millisecond)
+	s.Tick()
+
+	// Run a speculative simulation on the clone
+	clone := s.Clone()
+	for i := 0; i < 200; i++ {
+		clone.Tick()
+	}
+
+	assert.Equal(t, 202, count.Value())
+	assert.Equal(t, now.Add(2010*time.Millisecond), clone.Now())
+	assert.Equal(t, now.Add(10*time.Millisecond), s.Now())
+
+	// The original scheduler is unaffected
+	for i := 0; i < 10; i++ {
+		s.Tick()
+	}
+	assert.Equal(t, 212, count.Value())
+}
+
+func TestCloneDynamic(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newScheduler(now)
+	s.RunDynamic(func(time.Time, time.Duration) time.Duration { return 50 * time.Millisecond })
+	s.RunSequence([]Task{
+		func(time.Time, time.Duration) bool { return true },
+		func(time.Time, time.Duration) bool { return true },
+	}, time.Second)
+	s.RunBackoff(func() error { return nil }, 50*time.Millisecond, time.Second, 2)
+	s.RunDaily(func(time.Time, time.Duration) bool { return true }, 0, time.UTC)
+	s.Tick()
+	assert.Len(t, s.Jobs(), 4)
+
+	// The jobs are rescheduled by the scheduler executing them
+	clone := s.Clone()
+	clone.Advance(100 * time.Millisecond)
+	assert.Len(t, clone.Jobs(), 4)
+	assert.Len(t, s.Jobs(), 4)
+}
+
+func TestCloneHandles(t *testing.T) {
+	now := time.Unix(0, 0)
+	var count Counter
+
+	s := newScheduler(now)
+	h := s.Schedule(count.Inc(), now.Add(50*time.Millisecond), 0)
+	clone := s.Clone()
+
+	// Both schedulers run their own copy of the job
+	clone.Advance(100 * time.Millisecond)
+	assert.Equal(t, 1, count.Value())
+	assert.Equal(t, Pending, h.State())
+
+	s.Advance(100 * time.Millisecond)
+	assert.Equal(t, 2, count.Value())
+	assert.Equal(t, Done, h.State())
+}
+
+func TestCloneOptions(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newScheduler(now, WithMaxJobs(10), WithRand(42), WithSmoothing(20*time.Millisecond),
+		WithBudget(time.Second), WithResync(time.Second), WithStartAlignment(time.Minute))
+	s.rand.Float64()
+
+	clone := s.Clone()
+	assert.Equal(t, s.maxJobs, clone.maxJobs)
+	assert.Equal(t, s.smoothing, clone.smoothing)
+	assert.Equal(t, s.budget, clone.budget)
+	assert.Equal(t, s.resync, clone.resync)
+	assert.Equal(t, s.alignment, clone.alignment)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, s.rand.Float64(), clone.rand.Float64())
+	}
+}
+
+func TestSmoothing(t *testing.T) {
+	now := time.Unix(0, 0)
+	loadOf := func(s *Scheduler) (max int) {
+		load := make(map[int]int)
+		for _, job := range s.Jobs() {
+			if load[job.Bucket]++; load[job.Bucket] > max {
+				max = load[job.Bucket]
+			}
+		}
+		return
+	}
+
+	for _, tc := range []struct {
+		options []Option
+		maxLoad int
+	}{
+		{options: nil, maxLoad: 100},
+		{options: []Option{WithSmoothing(40 * time.Millisecond)}, maxLoad: 20},
+	} {
+		var count Counter
+		s := newScheduler(now, tc.options...)
+		for i := 0; i < 100; i++ {
+			s.RunEvery(count.Inc(), 100*time.Millisecond)
+		}
+
+		for i := 0; i < 500; i++ {
+			s.Tick()
+		}
+
+		assert.LessOrEqual(t, loadOf(s), tc.maxLoad)
+		assert.InDelta(t, 5000, count.Value(), 100)
+	}
+}
+
+func TestReleaseExecuted(t *testing.T) {
+	now := time.Unix(0, 0)
+	var count Counter
+
+	s := newScheduler(now, WithShards(2))
+	s.RunAfter(count.Inc(), 10*time.Millisecond)
+	s.RunAfter(count.Inc(), 1010*time.Millisecond)
+	h := s.Schedule(count.Inc(), now.Add(10*time.Millisecond), 0)
+	s.Advance(20 * time.Millisecond)
+	assert.Equal(t, Done, h.State())
+
+	// No stale job is left in the backing arrays of the bucket
+	bucket := s.bucketOf(tickOf(now.Add(10 * time.Millisecond)))
+	for _, job := range bucket.spare[:cap(bucket.spare)] {
+		assert.Nil(t, job.Task)
+	}
+	for _, job := range bucket.queue[len(bucket.queue):cap(bucket.queue)] {
+		assert.Nil(t, job.Task)
+	}
+	assert.Len(t, bucket.queue, 1)
+}
+
 func TestJobSize(t *testing.T) {
 	size := unsafe.Sizeof(job{})
-	assert.Equal(t, 24, int(size))
+	assert.Equal(t, 72, int(size))
 }
 
 // ----------------------------------------- Log -----------------------------------------
@@ -278,8 +1048,270 @@ func (c *Counter) Inc() Task {
 
 // ----------------------------------------- Scheduler -----------------------------------------
 
-func newScheduler(now time.Time) *Scheduler {
-	s := New()
+func newScheduler(now time.Time, options ...Option) *Scheduler {
+	s := New(options...)
 	s.Seek(now)
 	return s
 }
+
+func TestCollectDue(t *testing.T) {
+	now := time.Unix(0, 0)
+	var count Counter
+
+	s := newScheduler(now)
+	s.Run(count.Inc())
+	s.RunEvery(count.Inc(), 20*time.Millisecond)
+	s.RunAfter(count.Inc(), 20*time.Millisecond)
+
+	// Collected, but not executed until asked to
+	due := s.CollectDue()
+	assert.Equal(t, now, due.Time())
+	assert.Equal(t, 2, due.Len())
+	assert.Equal(t, 0, count.Value())
+	assert.Equal(t, now.Add(10*time.Millisecond), s.Now())
+
+	// Execute on a different goroutine, the recurring job is rescheduled
+	done := make(chan int)
+	go func() {
+		done <- s.Execute(due)
+	}()
+	assert.Equal(t, 2, <-done)
+
+	s.Tick()
+	s.Execute(s.CollectDue())
+	assert.Equal(t, 4, count.Value())
+}
+
+func TestWithExecutor(t *testing.T) {
+	var onThread atomic.Bool
+	work := make(chan func())
+	go func() {
+		runtime.LockOSThread()
+		for fn := range work {
+			onThread.Store(true)
+			fn()
+			onThread.Store(false)
+		}
+	}()
+	defer close(work)
+
+	var count Counter
+	s := newScheduler(time.Unix(0, 0), WithExecutor(func(fn func()) {
+		work <- fn
+	}))
+	s.RunEvery(func(now time.Time, elapsed time.Duration) bool {
+		assert.True(t, onThread.Load())
+		count.Inc()(now, elapsed)
+		return true
+	}, 20*time.Millisecond)
+
+	// Every tick waits for the executor, the recurring task is rescheduled
+	s.Advance(100 * time.Millisecond)
+	assert.Equal(t, 5, count.Value())
+}
+
+func TestWithExecutorPanic(t *testing.T) {
+	work := make(chan func(), 1)
+	go func() {
+		for fn := range work {
+			fn()
+		}
+	}()
+	defer close(work)
+
+	var count Counter
+	s := newScheduler(time.Unix(0, 0), WithExecutor(func(fn func()) {
+		work <- fn
+	}))
+	s.Run(func(now time.Time, elapsed time.Duration) bool {
+		panic("boom")
+	})
+	s.RunEvery(count.Inc(), 10*time.Millisecond)
+
+	// The panic is raised on the goroutine of the tick, and the executor keeps working
+	assert.PanicsWithValue(t, "boom", func() { s.Tick() })
+	s.Advance(30 * time.Millisecond)
+	assert.Equal(t, 3, count.Value())
+}
+
+func TestStep(t *testing.T) {
+	now := time.Unix(0, 0)
+	var count Counter
+
+	s := newScheduler(now)
+	s.RunEvery(count.Inc(), 40*time.Millisecond)
+
+	// The steps below the resolution add up over time
+	for i := 0; i < 5; i++ {
+		s.Step(16 * time.Millisecond)
+	}
+	assert.Equal(t, now.Add(80*time.Millisecond), s.Now())
+	assert.Equal(t, 2, count.Value())
+
+	assert.Equal(t, now.Add(80*time.Millisecond), s.Step(5*time.Millisecond))
+	assert.Equal(t, now.Add(90*time.Millisecond), s.Step(5*time.Millisecond))
+	assert.Equal(t, 3, count.Value())
+}
+
+func TestAdvance(t *testing.T) {
+	now := time.Unix(0, 0)
+	var count Counter
+
+	s := newScheduler(now)
+	s.RunEvery(count.Inc(), 100*time.Millisecond)
+	s.RunAfter(count.Inc(), 250*time.Millisecond)
+	s.RunAfter(count.Inc(), 5*time.Second)
+
+	// All of the intervening ticks are processed
+	assert.Equal(t, now.Add(time.Second), s.Advance(time.Second))
+	assert.Equal(t, 11, count.Value())
+
+	// Less than a tick does not move the clock
+	assert.Equal(t, now.Add(time.Second), s.Advance(5*time.Millisecond))
+	assert.Equal(t, 11, count.Value())
+}
+
+func TestShards(t *testing.T) {
+	now := time.Unix(0, 0)
+	var count Counter
+
+	s := newScheduler(now, WithShards(4))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				s.RunAfter(count.Inc(), 20*time.Millisecond)
+			}
+		}()
+	}
+
+	wg.Wait()
+	assert.Len(t, s.Jobs(), 800)
+	assert.Len(t, s.Clone().Jobs(), 800)
+
+	s.Advance(30 * time.Millisecond)
+	assert.Equal(t, 800, count.Value())
+}
+
+func TestRunOrder(t *testing.T) {
+	now := time.Unix(0, 0)
+	var order []int
+
+	// Overflow the inbox, the order must be preserved
+	s := newScheduler(now)
+	for i := 0; i < 3*inboxSize; i++ {
+		i := i
+		s.Run(func(time.Time, time.Duration) bool {
+			order = append(order, i)
+			return true
+		})
+	}
+
+	s.Tick()
+	assert.Len(t, order, 3*inboxSize)
+	for i, v := range order {
+		assert.Equal(t, i, v)
+	}
+}
+
+func TestRunConcurrent(t *testing.T) {
+	s := newScheduler(time.Unix(0, 0))
+	last := make([]int, 4)
+	var count atomic.Int32
+
+	// Tick while multiple producers schedule tasks
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				s.Tick()
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for p := 0; p < 4; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 1; i <= 1000; i++ {
+				i := i
+				s.Run(func(time.Time, time.Duration) bool {
+					assert.Equal(t, last[p]+1, i)
+					last[p] = i
+					count.Add(1)
+					return true
+				})
+			}
+		}(p)
+	}
+
+	wg.Wait()
+	assert.Eventually(t, func() bool {
+		return count.Load() == 4000
+	}, time.Second, time.Millisecond)
+	close(stop)
+}
+
+func TestRunAtPriority(t *testing.T) {
+	for _, options := range [][]Option{nil, {WithStableOrder()}} {
+		now := time.Unix(0, 0)
+		log := make(Log, 0, 4)
+
+		s := newScheduler(now, options...)
+		s.RunAt(log.Log("A"), now)
+		s.RunAtPriority(log.Log("B"), now, 1)
+		s.RunAt(log.Log("C"), now)
+		s.RunAtPriority(log.Log("D"), now, 5)
+		s.Tick()
+		assert.Equal(t, Log{"D", "B", "A", "C"}, log)
+	}
+}
+
+func TestRecurringOrder(t *testing.T) {
+	now := time.Unix(0, 0)
+	log := make(Log, 0, 16)
+
+	// The recurring jobs are moved across the buckets at a different pace, but keep running
+	// after the one-shot jobs and in the order in which they were first scheduled
+	s := newScheduler(now)
+	s.RunEveryAt(log.Log("A"), 300*time.Millisecond, now)
+	s.RunEveryAt(log.Log("B"), 200*time.Millisecond, now)
+	s.RunEveryAt(log.Log("C"), 100*time.Millisecond, now)
+	s.RunAt(log.Log("1"), now)
+	s.RunAt(log.Log("2"), now.Add(600*time.Millisecond))
+	s.RunAt(log.Log("3"), now.Add(600*time.Millisecond))
+
+	s.Tick()
+	assert.Equal(t, Log{"1", "A", "B", "C"}, log)
+
+	log = log[:0]
+	s.Advance(600 * time.Millisecond)
+	assert.Equal(t, Log{"2", "3", "A", "B", "C"}, log[len(log)-5:])
+}
+
+func TestStableOrder(t *testing.T) {
+	for _, tc := range []struct {
+		options []Option
+		expect  Log
+	}{
+		{expect: Log{"A", "B", "A"}},
+		{expect: Log{"A", "A", "B"}, options: []Option{WithStableOrder()}},
+		{expect: Log{"A", "A", "B"}, options: []Option{WithStableOrder(), WithShards(4)}},
+	} {
+		now := time.Unix(0, 0)
+		log := make(Log, 0, 4)
+
+		// The recurring job is rescheduled into a bucket which already contains a job
+		s := newScheduler(now, tc.options...)
+		s.RunEveryAt(log.Log("A"), 500*time.Millisecond, now)
+		s.RunAt(log.Log("B"), now.Add(500*time.Millisecond))
+		s.Advance(600 * time.Millisecond)
+		assert.Equal(t, tc.expect, log)
+	}
+}